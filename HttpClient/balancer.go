@@ -0,0 +1,225 @@
+package HttpClient
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNoHealthyEndpoint = errors.New("no healthy endpoint")
+
+type BalanceStrategy int
+
+const (
+	RoundRobin BalanceStrategy = iota
+	Random
+	LeastOutstanding
+)
+
+type endpoint struct {
+	url         *url.URL
+	outstanding int
+	fails       int
+	downUntil   time.Time
+}
+
+type EndpointStatus struct {
+	Url         string
+	Healthy     bool
+	Outstanding int
+	Fails       int
+}
+
+// 客户端负载均衡：把请求分发到多个副本，连续失败的副本暂时摘除，冷却后重新加入
+type Balancer struct {
+	mu        sync.Mutex
+	host      string
+	endpoints []*endpoint
+	strategy  BalanceStrategy
+	next      int
+	maxFails  int
+	cooldown  time.Duration
+	rand      *rand.Rand
+}
+
+// host 为需要负载均衡的逻辑主机，如 users 或 api.internal:8080，
+// endpoints 为副本的基础地址，如 http://10.0.0.1:8080/api
+func NewBalancer(host string, strategy BalanceStrategy, endpoints ...string) (*Balancer, error) {
+	if host == "" {
+		return nil, errors.New("balancer host is required")
+	}
+
+	b := &Balancer{
+		host:     strings.ToLower(host),
+		strategy: strategy,
+		maxFails: 3,
+		cooldown: 30 * time.Second,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, errors.New("invalid endpoint " + e)
+		}
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		b.endpoints = append(b.endpoints, &endpoint{url: u})
+	}
+
+	if len(b.endpoints) == 0 {
+		return nil, errors.New("balancer has no endpoints")
+	}
+
+	return b, nil
+}
+
+// 连续失败 n 次后摘除，默认 3
+func (b *Balancer) MaxFails(n int) *Balancer {
+	b.maxFails = n
+	return b
+}
+
+// 摘除后的冷却时间，默认 30 秒
+func (b *Balancer) Cooldown(d time.Duration) *Balancer {
+	b.cooldown = d
+	return b
+}
+
+// 主机为 host 的请求，地址中的 scheme 和 host 会被替换为选中的副本，路径拼接在副本的基础路径之后，
+// 其他主机的请求（如重定向到外部地址）原样发送
+func (b *Balancer) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return b.roundTrip(next, req)
+		})
+	}
+}
+
+func (b *Balancer) Status() []EndpointStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	list := make([]EndpointStatus, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		list = append(list, EndpointStatus{
+			Url:         e.url.String(),
+			Healthy:     !now.Before(e.downUntil),
+			Outstanding: e.outstanding,
+			Fails:       e.fails,
+		})
+	}
+	return list
+}
+
+func (b *Balancer) pick() (*endpoint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	healthy := make([]*endpoint, 0, len(b.endpoints))
+	for i := range b.endpoints {
+		e := b.endpoints[(b.next+i)%len(b.endpoints)]
+		if !now.Before(e.downUntil) {
+			healthy = append(healthy, e)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, ErrNoHealthyEndpoint
+	}
+
+	var picked *endpoint
+	switch b.strategy {
+	case Random:
+		picked = healthy[b.rand.Intn(len(healthy))]
+	case LeastOutstanding:
+		//并发数相同时按轮询顺序选择
+		for _, e := range healthy {
+			if picked == nil || e.outstanding < picked.outstanding {
+				picked = e
+			}
+		}
+	default:
+		picked = healthy[0]
+	}
+
+	for i, e := range b.endpoints {
+		if e == picked {
+			b.next = (i + 1) % len(b.endpoints)
+		}
+	}
+	picked.outstanding++
+	return picked, nil
+}
+
+func (b *Balancer) done(e *endpoint, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.outstanding--
+	if !failed {
+		e.fails = 0
+		return
+	}
+
+	e.fails++
+	if e.fails >= b.maxFails {
+		e.downUntil = time.Now().Add(b.cooldown)
+		//冷却后重新加入，再失败一次就会再次摘除
+		e.fails = b.maxFails - 1
+	}
+}
+
+func (b *Balancer) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if !strings.EqualFold(req.URL.Host, b.host) {
+		return next.RoundTrip(req)
+	}
+
+	e, err := b.pick()
+	if err != nil {
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Scheme = e.url.Scheme
+	out.URL.Host = e.url.Host
+	out.URL.Path = e.url.Path + out.URL.Path
+	if out.URL.RawPath != "" {
+		out.URL.RawPath = e.url.EscapedPath() + out.URL.RawPath
+	}
+	out.Host = ""
+
+	res, err := next.RoundTrip(out)
+	if err != nil {
+		b.done(e, true)
+		return nil, err
+	}
+
+	failed := res.StatusCode >= 500
+	if res.Body == nil || res.Body == http.NoBody {
+		b.done(e, failed)
+		return res, nil
+	}
+	res.Body = &balancedBody{ReadCloser: res.Body, done: func() { b.done(e, failed) }}
+	return res, nil
+}
+
+// 响应体关闭时才算请求结束
+type balancedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *balancedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
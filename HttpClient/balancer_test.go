@@ -0,0 +1,243 @@
+package HttpClient_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func replicas(n int, handler func(i int, w http.ResponseWriter, r *http.Request)) ([]string, func()) {
+	urls := make([]string, 0, n)
+	servers := make([]*httptest.Server, 0, n)
+	for i := 0; i < n; i++ {
+		i := i
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(i, w, r)
+		}))
+		servers = append(servers, server)
+		urls = append(urls, server.URL+"/api")
+	}
+	return urls, func() {
+		for _, s := range servers {
+			s.Close()
+		}
+	}
+}
+
+func TestBalancer_RoundRobin(t *testing.T) {
+	urls, closeAll := replicas(3, func(i int, w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%d %s", i, r.URL.RequestURI())
+	})
+	defer closeAll()
+
+	b, err := HttpClient.NewBalancer("users", HttpClient.RoundRobin, urls...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := HttpClient.NewRequest().Use(b.Middleware())
+
+	got := make([]string, 0)
+	for i := 0; i < 4; i++ {
+		resp, err := req.GET("http://users/v1/list", HttpClient.Data{"page": "1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, _ := resp.Content()
+		got = append(got, s)
+	}
+	expected := "0 /api/v1/list?page=1,1 /api/v1/list?page=1,2 /api/v1/list?page=1,0 /api/v1/list?page=1"
+	if strings.Join(got, ",") != expected {
+		t.Fatalf("unexpected distribution %v", got)
+	}
+
+	if _, err := HttpClient.NewBalancer("users", HttpClient.RoundRobin); err == nil {
+		t.Fatal("expected error for empty balancer")
+	}
+	if _, err := HttpClient.NewBalancer("", HttpClient.RoundRobin, urls...); err == nil {
+		t.Fatal("expected error for missing host")
+	}
+}
+
+func TestBalancer_Random(t *testing.T) {
+	urls, closeAll := replicas(2, func(i int, w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, i)
+	})
+	defer closeAll()
+
+	b, _ := HttpClient.NewBalancer("svc", HttpClient.Random, urls...)
+	req := HttpClient.NewRequest().Use(b.Middleware())
+	seen := map[string]int{}
+	for i := 0; i < 50; i++ {
+		resp, err := req.GET("http://svc/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, _ := resp.Content()
+		seen[s]++
+	}
+	if seen["0"] == 0 || seen["1"] == 0 {
+		t.Fatalf("random strategy never picked a replica: %v", seen)
+	}
+}
+
+func TestBalancer_LeastOutstanding(t *testing.T) {
+	release := make(chan struct{})
+	urls, closeAll := replicas(2, func(i int, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/slow" {
+			<-release
+		}
+		fmt.Fprint(w, i)
+	})
+	defer closeAll()
+
+	b, _ := HttpClient.NewBalancer("svc", HttpClient.LeastOutstanding, urls...)
+
+	//第一个请求占住副本 0
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if resp, err := HttpClient.NewRequest().Use(b.Middleware()).GET("http://svc/slow", nil); err == nil {
+			resp.Content()
+		}
+	}()
+	deadline := time.Now().Add(time.Second)
+	for b.Status()[0].Outstanding == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	req := HttpClient.NewRequest().Use(b.Middleware())
+	for i := 0; i < 3; i++ {
+		resp, err := req.GET("http://svc/fast", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, _ := resp.Content(); s != "1" {
+			t.Fatalf("request %d went to busy replica %s", i, s)
+		}
+	}
+
+	close(release)
+	wg.Wait()
+	for _, s := range b.Status() {
+		if s.Outstanding != 0 {
+			t.Fatalf("outstanding count leaked: %+v", b.Status())
+		}
+	}
+}
+
+func TestBalancer_PassiveHealth(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	urls, closeAll := replicas(2, func(i int, w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if i == 0 && down {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, i)
+	})
+	defer closeAll()
+
+	b, _ := HttpClient.NewBalancer("svc", HttpClient.RoundRobin, urls...)
+	b.MaxFails(2).Cooldown(100 * time.Millisecond)
+	req := HttpClient.NewRequest().Use(b.Middleware())
+
+	codes := make([]string, 0)
+	for i := 0; i < 6; i++ {
+		resp, err := req.GET("http://svc/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, _ := resp.Content()
+		codes = append(codes, fmt.Sprintf("%d:%s", resp.StatusCode(), s))
+	}
+	//两次失败后副本 0 被摘除
+	if strings.Join(codes, ",") != "502:,200:1,502:,200:1,200:1,200:1" {
+		t.Fatalf("unexpected responses %v", codes)
+	}
+	if status := b.Status(); status[0].Healthy || !status[1].Healthy {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	time.Sleep(150 * time.Millisecond)
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		resp, _ := req.GET("http://svc/", nil)
+		s, _ := resp.Content()
+		seen[s] = true
+	}
+	if !seen["0"] || !b.Status()[0].Healthy || b.Status()[0].Fails != 0 {
+		t.Fatalf("replica 0 was not re-admitted: %v %+v", seen, b.Status())
+	}
+}
+
+func TestBalancer_NoHealthyEndpoint(t *testing.T) {
+	b, _ := HttpClient.NewBalancer("svc", HttpClient.RoundRobin, "http://127.0.0.1:1")
+	b.MaxFails(1).Cooldown(time.Minute)
+	req := HttpClient.NewRequest().Use(b.Middleware())
+
+	if _, err := req.GET("http://svc/", nil); err == nil {
+		t.Fatal("expected connection error")
+	}
+	if _, err := req.GET("http://svc/", nil); err == nil || !strings.Contains(err.Error(), HttpClient.ErrNoHealthyEndpoint.Error()) {
+		t.Fatalf("expected ErrNoHealthyEndpoint, got %v", err)
+	}
+}
+
+func TestBalancer_Redirect(t *testing.T) {
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "external %s", r.URL.Path)
+	}))
+	defer external.Close()
+
+	urls, closeAll := replicas(2, func(i int, w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/local":
+			http.Redirect(w, r, "/done", http.StatusFound)
+		case "/api/away":
+			http.Redirect(w, r, external.URL+"/landing", http.StatusFound)
+		default:
+			fmt.Fprintf(w, "%d %s", i, r.URL.Path)
+		}
+	})
+	defer closeAll()
+
+	b, _ := HttpClient.NewBalancer("users", HttpClient.RoundRobin, urls...)
+	req := HttpClient.NewRequest().Use(b.Middleware())
+
+	//相对地址的重定向仍然发往副本，外部地址原样访问
+	resp, err := req.GET("http://users/local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "1 /api/done" {
+		t.Fatalf("unexpected response %q", s)
+	}
+
+	resp, err = req.GET("http://users/away", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "external /landing" {
+		t.Fatalf("unexpected response %q", s)
+	}
+
+	//其他主机的请求不经过副本
+	if resp, err := req.GET(external.URL+"/direct", nil); err != nil {
+		t.Fatal(err)
+	} else if s, _ := resp.Content(); s != "external /direct" {
+		t.Fatalf("unexpected response %q", s)
+	}
+}
@@ -0,0 +1,265 @@
+package HttpClient
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// 声明支持 gzip/deflate/br/zstd 响应，响应体会被透明解压；
+// 调用方自行设置了 Accept-Encoding 时不解压，原样返回响应体
+func (r *Request) Compression(b bool) *Request {
+	r.compression = b
+	return r
+}
+
+// 请求体达到 minSize 字节时使用 encoding（gzip/deflate/br/zstd）压缩后发送
+func (r *Request) CompressBody(encoding string, minSize int) *Request {
+	r.compressEncoding = strings.ToLower(encoding)
+	r.compressMinSize = minSize
+	return r
+}
+
+// 返回是否由这里添加了 Accept-Encoding，只有这种情况才需要解压响应体
+func (r *Request) initCompression(req *http.Request) (bool, error) {
+	decode := false
+	if r.compression && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		decode = true
+	}
+
+	if r.compressEncoding == "" || req.Body == nil || req.Header.Get("Content-Encoding") != "" {
+		return decode, nil
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return decode, err
+	}
+	req.Body.Close()
+
+	//空请求体压缩后反而多出压缩头
+	if len(b) > 0 && len(b) >= r.compressMinSize {
+		var buf bytes.Buffer
+		w, err := newEncoder(r.compressEncoding, &buf)
+		if err != nil {
+			return decode, err
+		}
+		if _, err := w.Write(b); err != nil {
+			return decode, err
+		}
+		if err := w.Close(); err != nil {
+			return decode, err
+		}
+		b = buf.Bytes()
+		req.Header.Set("Content-Encoding", r.compressEncoding)
+	}
+
+	req.ContentLength = int64(len(b))
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	return decode, nil
+}
+
+func newEncoder(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "deflate":
+		return zlib.NewWriter(w), nil
+	case "br":
+		return brotli.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+func newDecoder(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		//deflate 按规范是 zlib 格式，但不少服务端直接发送裸 deflate 数据
+		br := bufio.NewReader(r)
+		if h, err := br.Peek(2); err == nil && h[0]&0x0f == 8 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	case "br":
+		return ioutil.NopCloser(brotli.NewReader(r)), nil
+	case "zstd":
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+}
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (d *decodedBody) Close() error {
+	var err error
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		if e := d.closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// 首次读取时才创建解压器，流式响应不会在返回前阻塞；
+// 空响应体没有压缩头，直接返回 io.EOF
+type lazyDecoder struct {
+	reader    io.Reader
+	encodings []string
+	decoder   io.Reader
+	closers   []io.Closer
+	err       error
+}
+
+func (l *lazyDecoder) Read(p []byte) (int, error) {
+	if l.decoder == nil && l.err == nil {
+		l.init()
+	}
+	if l.err != nil {
+		return 0, l.err
+	}
+	return l.decoder.Read(p)
+}
+
+func (l *lazyDecoder) init() {
+	br := bufio.NewReader(l.reader)
+	if _, err := br.Peek(1); err != nil {
+		l.err = err
+		return
+	}
+
+	var reader io.Reader = br
+	for i := len(l.encodings) - 1; i >= 0; i-- {
+		d, err := newDecoder(l.encodings[i], reader)
+		if err != nil {
+			l.err = err
+			return
+		}
+		reader = d
+		l.closers = append(l.closers, d)
+	}
+	l.decoder = reader
+}
+
+func (l *lazyDecoder) Close() error {
+	var err error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if e := l.closers[i].Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// 统计线上字节数，decode 为 true 时按 Content-Encoding 解压响应体
+func (r *Response) decodeBody(decode bool) {
+	res := r.Resp
+	if res == nil || res.Body == nil {
+		return
+	}
+
+	//Transport 自动解压 gzip 时无法得知线上字节数
+	if res.Uncompressed {
+		return
+	}
+
+	body := res.Body
+	r.wire = &countingReader{reader: body}
+	res.Body = &decodedBody{Reader: r.wire, closers: []io.Closer{body}}
+	if !decode || res.ContentLength == 0 || res.StatusCode == http.StatusNoContent || res.StatusCode == http.StatusNotModified ||
+		(res.Request != nil && res.Request.Method == http.MethodHead) {
+		return
+	}
+
+	encodings := make([]string, 0)
+	for _, encoding := range strings.Split(res.Header.Get("Content-Encoding"), ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding == "" || encoding == "identity" {
+			continue
+		}
+		//不认识的编码保持原样返回
+		switch encoding {
+		case "gzip", "x-gzip", "deflate", "br", "zstd":
+		default:
+			return
+		}
+		encodings = append(encodings, encoding)
+	}
+	if len(encodings) == 0 {
+		return
+	}
+
+	decoder := &lazyDecoder{reader: r.wire, encodings: encodings}
+	res.Body = &decodedBody{Reader: decoder, closers: []io.Closer{body, decoder}}
+
+	r.encoding = encodings
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+// 响应的原始 Content-Encoding
+func (r *Response) ContentEncoding() string {
+	if r == nil {
+		return ""
+	}
+	return strings.Join(r.encoding, ", ")
+}
+
+// 线上传输的响应体字节数，读取完响应体后有效；
+// 未开启 Compression 时 Transport 会自行请求并解压 gzip，这种响应无法统计线上字节数，返回 -1
+func (r *Response) WireSize() int64 {
+	if r == nil || r.wire == nil {
+		return -1
+	}
+	return r.wire.n
+}
+
+// 解压后的响应体字节数，读取完响应体后有效
+func (r *Response) DecodedSize() int64 {
+	if r == nil {
+		return 0
+	}
+	return int64(len(r.body))
+}
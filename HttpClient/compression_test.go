@@ -0,0 +1,184 @@
+package HttpClient_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+var compressPayload = strings.Repeat("goutils compression payload ", 200)
+
+func encodeWith(t *testing.T, encoding string, s string) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "zstd":
+		w, _ = zstd.NewWriter(&buf)
+	}
+	w.Write([]byte(s))
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestResponse_Decode(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "raw-deflate", "br", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			body := encodeWith(t, encoding, compressPayload)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != "gzip, deflate, br, zstd" {
+					t.Errorf("unexpected Accept-Encoding %q", r.Header.Get("Accept-Encoding"))
+				}
+				w.Header().Set("Content-Encoding", strings.TrimPrefix(encoding, "raw-"))
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			resp, err := HttpClient.NewRequest().Compression(true).GET(server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := resp.Content()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != compressPayload {
+				t.Fatalf("unexpected body %q", s)
+			}
+			if resp.WireSize() != int64(len(body)) || resp.DecodedSize() != int64(len(compressPayload)) {
+				t.Fatalf("unexpected sizes wire=%d decoded=%d", resp.WireSize(), resp.DecodedSize())
+			}
+			if resp.ContentEncoding() != strings.TrimPrefix(encoding, "raw-") || resp.Headers().Get("Content-Encoding") != "" {
+				t.Fatalf("unexpected encoding %q", resp.ContentEncoding())
+			}
+		})
+	}
+}
+
+func TestRequest_CompressBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, _ = gzip.NewReader(r.Body)
+		}
+		b, _ := ioutil.ReadAll(reader)
+		w.Header().Set("X-Encoding", r.Header.Get("Content-Encoding"))
+		w.Write(b)
+	}))
+	defer server.Close()
+
+	resp, err := HttpClient.NewRequest().Json().CompressBody("gzip", 1024).POST(server.URL, HttpClient.Data{"a": compressPayload})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := resp.Content()
+	if resp.Headers().Get("X-Encoding") != "gzip" || !strings.Contains(s, compressPayload) {
+		t.Fatalf("request body was not compressed")
+	}
+
+	resp, err = HttpClient.NewRequest().Json().CompressBody("gzip", 1024).POST(server.URL, HttpClient.Data{"a": "small"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ = resp.Content()
+	if resp.Headers().Get("X-Encoding") != "" || s != `{"a":"small"}` {
+		t.Fatalf("small body should not be compressed: %q", s)
+	}
+}
+
+func TestCompression_EmptyBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Encoding", r.Header.Get("Content-Encoding"))
+		w.Header().Set("X-Length", strconv.Itoa(len(b)))
+		//分块传输的空 gzip 响应
+		w.Header().Set("Content-Encoding", "gzip")
+		w.(http.Flusher).Flush()
+	}))
+	defer server.Close()
+
+	resp, err := HttpClient.NewRequest().Compression(true).SetRawBody([]byte{}).CompressBody("gzip", 0).PUT(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := resp.Content()
+	if err != nil || s != "" {
+		t.Fatalf("unexpected body %q %v", s, err)
+	}
+	if resp.Headers().Get("X-Encoding") != "" || resp.Headers().Get("X-Length") != "0" {
+		t.Fatalf("empty body should not be compressed: %q", resp.Headers().Get("X-Encoding"))
+	}
+	if resp.ContentEncoding() != "gzip" {
+		t.Fatalf("unexpected encoding %q", resp.ContentEncoding())
+	}
+}
+
+// 调用方自行设置 Accept-Encoding 时原样返回响应体
+func TestCompression_CallerAcceptEncoding(t *testing.T) {
+	body := encodeWith(t, "gzip", compressPayload)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	resp, err := HttpClient.NewRequest().Compression(true).
+		AddHeaders(map[string]string{"Accept-Encoding": "gzip"}).
+		GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := resp.Content()
+	if s != string(body) || resp.Headers().Get("Content-Encoding") != "gzip" || resp.ContentEncoding() != "" {
+		t.Fatalf("body should not be decoded, got %d bytes", len(s))
+	}
+}
+
+// 流式响应在第一块数据到达前就要返回
+func TestCompression_Stream(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write(encodeWith(t, "gzip", "done"))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := HttpClient.NewRequest().Compression(true).Stream(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+
+	release <- struct{}{}
+	b, err := ioutil.ReadAll(resp.Resp.Body)
+	if err != nil || string(b) != "done" {
+		t.Fatalf("unexpected body %q %v", b, err)
+	}
+}
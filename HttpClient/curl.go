@@ -0,0 +1,544 @@
+package HttpClient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 设置请求方法、地址和参数但不发送，配合 ToCurl 或 Do 使用
+func (r *Request) Prepare(method string, reqUrl string, data Data) *Request {
+	r.method = strings.ToUpper(method)
+	r.rawUrl = reqUrl
+	r.url = reqUrl
+	r.data = data
+	r.files = nil
+	return r
+}
+
+// 原样发送的请求体，优先于 Data
+func (r *Request) SetRawBody(body []byte) *Request {
+	r.rawBody = body
+	return r
+}
+
+// 按 Prepare 或 ParseCurl 设置好的方法、地址和参数发送请求
+func (r *Request) Do() (*Response, error) {
+	//ParseCurl 解析出的原始请求体只属于这一次请求，不影响之后的 POST 等调用
+	if body, ok := r.data.([]byte); ok {
+		return r.requestBody(r.method, r.rawUrl, r.headers, body)
+	}
+
+	data, _ := r.data.(Data)
+	if r.files != nil {
+		return r.sendFile(r.rawUrl, r.files, data)
+	}
+
+	switch r.method {
+	case http.MethodGet:
+		return r.GET(r.rawUrl, data)
+	case http.MethodPost:
+		return r.POST(r.rawUrl, data)
+	case http.MethodPut:
+		return r.PUT(r.rawUrl, data)
+	case http.MethodDelete:
+		return r.DELETE(r.rawUrl, data)
+	default:
+		return r.request(r.method, r.rawUrl, data)
+	}
+}
+
+// 将最近一次发送（或 Prepare）的请求导出为 curl 命令
+func (r *Request) ToCurl() (string, error) {
+	if r.method == "" || r.rawUrl == "" {
+		return "", errors.New("method and url is required")
+	}
+
+	data, _ := r.data.(Data)
+	rawBody, _ := r.data.([]byte)
+	files := r.files
+
+	var req *http.Request
+	var err error
+	if files != nil || rawBody != nil {
+		var body io.Reader
+		if rawBody != nil {
+			body = bytes.NewReader(rawBody)
+		}
+		req, err = http.NewRequest(r.method, r.rawUrl, body)
+		if err != nil {
+			return "", err
+		}
+		r.initHeaders(req)
+		r.initCookies(req)
+		r.initBasicAuth(req)
+	} else {
+		req, err = r.newHTTPRequest(r.method, r.rawUrl, data)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	args := []string{"curl"}
+	if r.method == http.MethodHead {
+		args = append(args, "-I")
+	} else if (r.method != http.MethodGet || len(rawBody) > 0) && !(files != nil && r.method == http.MethodPost) {
+		args = append(args, "-X", r.method)
+	}
+	args = append(args, shellQuote(req.URL.String()))
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	basicAuth := r.username != "" || r.password != ""
+	for _, k := range keys {
+		switch {
+		case k == "Cookie":
+			continue
+		case k == "Authorization" && basicAuth:
+			continue
+		case k == "Content-Type" && files != nil:
+			continue
+		case k == "Accept-Encoding" && r.compression && req.Header.Get(k) == acceptEncoding:
+			continue
+		}
+		for _, v := range req.Header[k] {
+			args = append(args, "-H", shellQuote(k+": "+v))
+		}
+	}
+
+	if cookie := req.Header.Get("Cookie"); cookie != "" {
+		args = append(args, "-b", shellQuote(cookie))
+	}
+	if basicAuth {
+		args = append(args, "-u", shellQuote(r.username+":"+r.password))
+	}
+	if r.compression {
+		args = append(args, "--compressed")
+	}
+	if r.proxy != "" {
+		args = append(args, "-x", shellQuote(r.proxy))
+		if r.proxyUsername != "" {
+			args = append(args, "-U", shellQuote(r.proxyUsername+":"+r.proxyPassword))
+		}
+	}
+	if r.tlsClientConfig != nil && r.tlsClientConfig.InsecureSkipVerify {
+		args = append(args, "-k")
+	}
+
+	if files != nil {
+		for _, field := range sortedFileKeys(files) {
+			args = append(args, "-F", shellQuote(field+"=@"+files[field]))
+		}
+		for _, k := range sortedKeys(data) {
+			v, ok := data[k].(string)
+			if !ok {
+				b, err := json.Marshal(data[k])
+				if err != nil {
+					return "", err
+				}
+				v = string(b)
+			}
+			args = append(args, "--form-string", shellQuote(k+"="+v))
+		}
+	} else if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		if len(b) > 0 {
+			args = append(args, "--data-raw", shellQuote(string(b)))
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// 解析 curl 命令（例如浏览器的 Copy as cURL），返回已 Prepare 好的 Request，调用 Do 发送
+func ParseCurl(command string) (*Request, error) {
+	args, err := splitShell(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.New("curl: not a curl command")
+	}
+
+	r := NewRequest()
+	method, reqUrl := "", ""
+	get, head := false, false
+	body := make([]string, 0)
+	forms := make([]string, 0)
+	formStrings := map[int]bool{}
+
+	for i := 1; i < len(args); i++ {
+		name, value, hasValue := args[i], "", false
+		if strings.HasPrefix(name, "--") {
+			if idx := strings.Index(name, "="); idx > 0 {
+				name, value, hasValue = name[:idx], name[idx+1:], true
+			}
+		} else if len(name) > 2 && name[0] == '-' && strings.Contains("XHdbuxFAemUo", name[1:2]) {
+			//-XPOST 这类值紧跟在短选项后的写法
+			name, value, hasValue = name[:2], name[2:], true
+		}
+
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			i++
+			if i >= len(args) {
+				return "", fmt.Errorf("curl: option %s requires a value", name)
+			}
+			return args[i], nil
+		}
+
+		switch name {
+		case "-X", "--request":
+			if method, err = next(); err != nil {
+				return nil, err
+			}
+			method = strings.ToUpper(method)
+		case "-H", "--header":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			kv := strings.SplitN(v, ":", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("curl: invalid header %q", v)
+			}
+			r.AddHeaders(map[string]string{http.CanonicalHeaderKey(strings.TrimSpace(kv[0])): strings.TrimSpace(kv[1])})
+		case "-A", "--user-agent", "-e", "--referer":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			header := "User-Agent"
+			if name == "-e" || name == "--referer" {
+				header = "Referer"
+			}
+			r.AddHeaders(map[string]string{header: v})
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(v, "@") && name != "--data-raw" {
+				b, err := ioutil.ReadFile(v[1:])
+				if err != nil {
+					return nil, err
+				}
+				v = string(b)
+				if name != "--data-binary" {
+					v = strings.Replace(strings.Replace(v, "\r", "", -1), "\n", "", -1)
+				}
+			}
+			body = append(body, v)
+		case "--data-urlencode":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if idx := strings.Index(v, "="); idx >= 0 {
+				v = v[:idx+1] + url.QueryEscape(v[idx+1:])
+			} else {
+				v = url.QueryEscape(v)
+			}
+			body = append(body, v)
+		case "-F", "--form", "--form-string":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if name == "--form-string" {
+				formStrings[len(forms)] = true
+			}
+			forms = append(forms, v)
+		case "-b", "--cookie":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.Contains(v, "=") {
+				return nil, fmt.Errorf("curl: cookie jar file %q is not supported", v)
+			}
+			for _, pair := range strings.Split(v, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 {
+					r.AddCookies(map[string]string{kv[0]: kv[1]})
+				}
+			}
+		case "-u", "--user":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			kv := strings.SplitN(v, ":", 2)
+			if len(kv) == 1 {
+				kv = append(kv, "")
+			}
+			r.SetBasicAuth(kv[0], kv[1])
+		case "-x", "--proxy":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.Contains(v, "://") {
+				v = "http://" + v
+			}
+			r.Proxy(v)
+		case "-U", "--proxy-user":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			kv := strings.SplitN(v, ":", 2)
+			if len(kv) == 1 {
+				kv = append(kv, "")
+			}
+			r.ProxyAuth(kv[0], kv[1])
+		case "-m", "--max-time":
+			v, err := next()
+			if err != nil {
+				return nil, err
+			}
+			seconds, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("curl: invalid max-time %q", v)
+			}
+			if seconds < 1 {
+				seconds = 1
+			}
+			r.SetTimeout(int(seconds))
+		case "--url":
+			if reqUrl, err = next(); err != nil {
+				return nil, err
+			}
+		case "-k", "--insecure":
+			r.insecure()
+		case "--compressed":
+			r.Compression(true)
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			head = true
+		case "-L", "--location", "-s", "--silent", "-S", "--show-error", "-v", "--verbose",
+			"-i", "--include", "-N", "--no-buffer", "--http1.1", "--http2", "--globoff":
+		case "-o", "--output", "--connect-timeout", "--retry", "-w", "--write-out":
+			if _, err := next(); err != nil {
+				return nil, err
+			}
+		default:
+			if !strings.HasPrefix(name, "-") {
+				reqUrl = name
+				continue
+			}
+			//-sSLk 这类合并的开关选项
+			if len(name) > 2 && name[1] != '-' && strings.Trim(name[1:], "sSLvikNGI") == "" {
+				if strings.Contains(name, "k") {
+					r.insecure()
+				}
+				if strings.Contains(name, "G") {
+					get = true
+				}
+				if strings.Contains(name, "I") {
+					head = true
+				}
+				continue
+			}
+			return nil, fmt.Errorf("curl: unsupported option %s", name)
+		}
+	}
+
+	if reqUrl == "" {
+		return nil, errors.New("curl: no url specified")
+	}
+	if !strings.Contains(reqUrl, "://") {
+		reqUrl = "http://" + reqUrl
+	}
+
+	data := strings.Join(body, "&")
+	switch {
+	case get:
+		if data != "" {
+			sep := "?"
+			if strings.Contains(reqUrl, "?") {
+				sep = "&"
+			}
+			reqUrl += sep + data
+		}
+		if method == "" {
+			method = http.MethodGet
+		}
+		r.Prepare(method, reqUrl, nil)
+	case len(forms) > 0:
+		if method == "" {
+			method = http.MethodPost
+		}
+		files := File{}
+		fields := Data{}
+		for i, form := range forms {
+			kv := strings.SplitN(form, "=", 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("curl: invalid form field %q", form)
+			}
+			if strings.HasPrefix(kv[1], "@") && !formStrings[i] {
+				files[kv[0]] = strings.SplitN(kv[1][1:], ";", 2)[0]
+			} else {
+				fields[kv[0]] = kv[1]
+			}
+		}
+		r.Prepare(method, reqUrl, fields)
+		r.files = files
+	case len(body) > 0:
+		if method == "" {
+			method = http.MethodPost
+		}
+		if _, ok := r.headers["Content-Type"]; !ok {
+			r.AddHeaders(map[string]string{"Content-Type": "application/x-www-form-urlencoded"})
+		}
+		//-X GET 不带 -G 时 curl 仍在请求体中发送数据
+		r.Prepare(method, reqUrl, nil)
+		r.data = []byte(data)
+	default:
+		if method == "" {
+			method = http.MethodGet
+		}
+		r.Prepare(method, reqUrl, nil)
+	}
+
+	if head {
+		r.method = http.MethodHead
+	}
+
+	return r, nil
+}
+
+func (r *Request) insecure() {
+	if r.tlsClientConfig == nil {
+		cfg, _ := NewTLSConfig()
+		r.tlsClientConfig = cfg
+	}
+	r.tlsClientConfig.InsecureSkipVerify = true
+}
+
+// 按 POSIX shell 规则拆分命令行，支持单引号、双引号、$'...' 和反斜杠续行
+func splitShell(s string) ([]string, error) {
+	args := make([]string, 0)
+	var cur strings.Builder
+	inArg := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(s) {
+				continue
+			}
+			i++
+			if s[i] == '\n' {
+				continue
+			}
+			if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+				continue
+			}
+			cur.WriteByte(s[i])
+			inArg = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("curl: unterminated single quote")
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\\\"$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				cur.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("curl: unterminated double quote")
+			}
+			inArg = true
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			i += 2
+			for ; i < len(s) && s[i] != '\''; i++ {
+				if s[i] != '\\' || i+1 >= len(s) {
+					cur.WriteByte(s[i])
+					continue
+				}
+				i++
+				switch s[i] {
+				case 'n':
+					cur.WriteByte('\n')
+				case 't':
+					cur.WriteByte('\t')
+				case 'r':
+					cur.WriteByte('\r')
+				case 'x':
+					if i+2 < len(s) {
+						if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+							cur.WriteByte(byte(b))
+							i += 2
+							continue
+						}
+					}
+					cur.WriteString(`\x`)
+				case 'u':
+					if i+4 < len(s) {
+						if n, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+							cur.WriteRune(rune(n))
+							i += 4
+							continue
+						}
+					}
+					cur.WriteString(`\u`)
+				default:
+					cur.WriteByte(s[i])
+				}
+			}
+			if i >= len(s) {
+				return nil, errors.New("curl: unterminated $'...' string")
+			}
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, cur.String())
+	}
+
+	return args, nil
+}
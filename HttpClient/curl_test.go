@@ -0,0 +1,153 @@
+package HttpClient_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func TestRequest_ToCurl(t *testing.T) {
+	req := HttpClient.NewRequest().
+		Json().
+		AddCookies(map[string]string{"b": "2", "a": "1"}).
+		SetBasicAuth("user", "it's").
+		Prepare(http.MethodPost, "http://example.com/api", HttpClient.Data{"name": "x", "ids": []int{1, 2}})
+
+	s, err := req.ToCurl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `curl -X POST 'http://example.com/api' -H 'Content-Type: application/json;charset=utf-8' -b 'a=1; b=2' -u 'user:it'\''s' --data-raw '{"ids":[1,2],"name":"x"}'`
+	if s != expected {
+		t.Fatalf("unexpected curl command:\n%s\n%s", s, expected)
+	}
+
+	s, _ = HttpClient.NewRequest().Compression(true).Prepare(http.MethodGet, "http://example.com/api?x=1", HttpClient.Data{"b": 2, "a": "1"}).ToCurl()
+	if s != `curl 'http://example.com/api?x=1&a=1&b=2' --compressed` {
+		t.Fatalf("unexpected curl command: %s", s)
+	}
+}
+
+func TestRequest_ToCurlUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	req := HttpClient.NewRequest()
+	if _, err := req.Upload(server.URL, HttpClient.File{"file": "../go.mod"}, HttpClient.Data{"n": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := req.ToCurl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != `curl '`+server.URL+`' -F 'file=@../go.mod' --form-string 'n=1'` {
+		t.Fatalf("unexpected curl command: %s", s)
+	}
+}
+
+func TestParseCurl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		sid := ""
+		if c, err := r.Cookie("sid"); err == nil {
+			sid = c.Value
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte(strings.Join([]string{
+			r.Method, r.URL.RawQuery, r.Header.Get("Content-Type"), r.Header.Get("X-Trace"),
+			user + ":" + pass, sid, string(body),
+		}, "|")))
+	}))
+	defer server.Close()
+
+	req, err := HttpClient.ParseCurl(`curl '` + server.URL + `/api?q=1' \
+  -H 'content-type: application/json' \
+  -H "X-Trace: \"abc\"" \
+  -b 'sid=s1; lang=zh' \
+  -u admin:secret \
+  --data-raw $'{"text":"it\'s\\n"}' \
+  --compressed`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := req.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := resp.Content()
+	expected := `POST|q=1|application/json|"abc"|admin:secret|s1|{"text":"it's\n"}`
+	if s != expected {
+		t.Fatalf("unexpected request:\n%s\n%s", s, expected)
+	}
+
+	//解析出的请求体只用于 Do，之后的请求使用自己的参数
+	resp, err = req.POST(server.URL, HttpClient.Data{"x": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ = resp.Content(); !strings.HasSuffix(s, "|x=1") {
+		t.Fatalf("unexpected request: %s", s)
+	}
+
+	//没有 -G 时 GET 也在请求体中发送数据
+	req, err = HttpClient.ParseCurl(`curl -X GET ` + server.URL + ` -d a=1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = req.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ = resp.Content(); s != "GET||application/x-www-form-urlencoded||:||a=1" {
+		t.Fatalf("unexpected request: %s", s)
+	}
+	if s, _ = req.ToCurl(); s != `curl -X GET '`+server.URL+`' -H 'Content-Type: application/x-www-form-urlencoded' --data-raw 'a=1'` {
+		t.Fatalf("unexpected curl command: %s", s)
+	}
+
+	req, err = HttpClient.ParseCurl(`curl -sSL -G -XGET ` + server.URL + ` -d a=1 --data-urlencode 'b=x y'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = req.Do()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ = resp.Content()
+	if !strings.HasPrefix(s, "GET|a=1&b=x+y|") {
+		t.Fatalf("unexpected request: %s", s)
+	}
+
+	if _, err := HttpClient.ParseCurl(`curl --unknown-flag x http://example.com`); err == nil {
+		t.Fatal("expected error for unsupported option")
+	}
+}
+
+func TestParseCurl_RoundTrip(t *testing.T) {
+	original := HttpClient.NewRequest().
+		AddHeaders(map[string]string{"X-Id": "1", "Content-Type": "application/x-www-form-urlencoded"}).
+		Prepare(http.MethodPut, "http://example.com/items/1", HttpClient.Data{"name": "a b", "n": 2})
+
+	cmd, err := original.ToCurl()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := HttpClient.ParseCurl(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := parsed.ToCurl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != again {
+		t.Fatalf("round trip mismatch:\n%s\n%s", cmd, again)
+	}
+}
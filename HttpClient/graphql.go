@@ -0,0 +1,225 @@
+package HttpClient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+	// 服务端已注册的持久化查询的 sha256，设置后可以不传 Query
+	Hash string `json:"-"`
+}
+
+type GraphQLResponse struct {
+	Data       jsoniter.RawMessage    `json:"data"`
+	Errors     GraphQLErrors          `json:"errors"`
+	Extensions map[string]interface{} `json:"extensions"`
+	Response   *Response              `json:"-"`
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// errors 数组中的一项
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("graphql: %s (path %s)", e.Message, strings.Join(path, "."))
+}
+
+// extensions.code，如 UNAUTHENTICATED、BAD_USER_INPUT
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// 响应中的全部错误，data 可能同时包含部分结果
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	switch len(e) {
+	case 0:
+		return "graphql: no errors"
+	case 1:
+		return e[0].Error()
+	default:
+		return fmt.Sprintf("%s (and %d more errors)", e[0].Error(), len(e)-1)
+	}
+}
+
+var ErrPersistedQueryNotFound = errors.New("graphql: persisted query not found and no query text to register")
+
+type GraphQL struct {
+	request   *Request
+	url       string
+	persisted bool
+	usesGet   bool
+}
+
+// 使用当前 Request 的请求头、认证、代理等配置请求 GraphQL 接口
+func (r *Request) GraphQL(url string) *GraphQL {
+	return &GraphQL{request: r, url: url}
+}
+
+// 启用自动持久化查询（APQ）：先只发送查询的 sha256，服务端未缓存时再带上查询语句重发
+func (g *GraphQL) PersistedQueries(b bool) *GraphQL {
+	g.persisted = b
+	return g
+}
+
+// 只发送 hash 的请求改用 GET，便于 CDN 缓存
+func (g *GraphQL) UseGETForHashedQueries(b bool) *GraphQL {
+	g.usesGet = b
+	return g
+}
+
+func (g *GraphQL) Query(query string, variables map[string]interface{}, v interface{}) error {
+	return g.Do(&GraphQLRequest{Query: query, Variables: variables}, v)
+}
+
+// 发送请求并将 data 解析到 v，响应包含 errors 时返回 GraphQLErrors
+func (g *GraphQL) Do(req *GraphQLRequest, v interface{}) error {
+	res, err := g.Send(req)
+	if err != nil {
+		return err
+	}
+
+	if v != nil && len(res.Data) > 0 && string(res.Data) != "null" {
+		if err := json.Unmarshal(res.Data, v); err != nil {
+			return &DecodeError{Err: err, Body: res.Data, Response: res.Response}
+		}
+	}
+
+	if len(res.Errors) > 0 {
+		return res.Errors
+	}
+	return nil
+}
+
+// 发送请求并返回完整的响应，errors 不会作为错误返回
+func (g *GraphQL) Send(req *GraphQLRequest) (*GraphQLResponse, error) {
+	if !g.persisted && req.Hash == "" {
+		return g.send(req, false)
+	}
+
+	hash := req.Hash
+	if hash == "" {
+		sum := sha256.Sum256([]byte(req.Query))
+		hash = hex.EncodeToString(sum[:])
+	}
+
+	full := *req
+	full.Extensions = map[string]interface{}{}
+	for k, v := range req.Extensions {
+		full.Extensions[k] = v
+	}
+	full.Extensions["persistedQuery"] = map[string]interface{}{"version": 1, "sha256Hash": hash}
+
+	hashed := full
+	hashed.Query = ""
+	res, err := g.send(&hashed, g.usesGet)
+	if err != nil || !res.persistedQueryMissing() {
+		return res, err
+	}
+
+	if full.Query == "" {
+		return res, ErrPersistedQueryNotFound
+	}
+	return g.send(&full, false)
+}
+
+func (g *GraphQL) send(req *GraphQLRequest, usesGet bool) (*GraphQLResponse, error) {
+	var resp *Response
+	var err error
+
+	if usesGet {
+		query := url.Values{}
+		if req.Query != "" {
+			query.Set("query", req.Query)
+		}
+		if req.OperationName != "" {
+			query.Set("operationName", req.OperationName)
+		}
+		for name, v := range map[string]map[string]interface{}{"variables": req.Variables, "extensions": req.Extensions} {
+			if len(v) == 0 {
+				continue
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			query.Set(name, string(b))
+		}
+
+		reqUrl := g.url
+		if strings.Contains(reqUrl, "?") {
+			reqUrl += "&" + query.Encode()
+		} else {
+			reqUrl += "?" + query.Encode()
+		}
+		resp, err = g.request.request(http.MethodGet, reqUrl, nil)
+	} else {
+		var b []byte
+		if b, err = json.Marshal(req); err != nil {
+			return nil, err
+		}
+		resp, err = g.request.sendJSON(http.MethodPost, g.url, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := resp.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GraphQLResponse{Response: resp}
+	decodeErr := json.Unmarshal(b, res)
+
+	//不少服务端在出错时返回 4xx/5xx 并附带 errors，优先返回 GraphQL 错误
+	ok := resp.StatusCode() >= 200 && resp.StatusCode() <= 299
+	if !ok && (decodeErr != nil || len(res.Errors) == 0) {
+		return nil, &StatusError{StatusCode: resp.StatusCode(), Status: resp.Resp.Status, Body: b, Response: resp}
+	}
+	if decodeErr != nil {
+		return nil, &DecodeError{Err: decodeErr, Body: b, Response: resp}
+	}
+	return res, nil
+}
+
+func (r *GraphQLResponse) persistedQueryMissing() bool {
+	for _, e := range r.Errors {
+		switch {
+		case e.Message == "PersistedQueryNotFound", e.Code() == "PERSISTED_QUERY_NOT_FOUND",
+			e.Message == "PersistedQueryNotSupported", e.Code() == "PERSISTED_QUERY_NOT_SUPPORTED":
+			return true
+		}
+	}
+	return false
+}
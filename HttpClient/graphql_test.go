@@ -0,0 +1,160 @@
+package HttpClient_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+// 一个支持自动持久化查询的极简 GraphQL 服务
+type graphqlServer struct {
+	mu       sync.Mutex
+	cache    map[string]string
+	requests []string
+}
+
+func (s *graphqlServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+		Extensions    map[string]interface{} `json:"extensions"`
+	}
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		json.Unmarshal([]byte(q.Get("variables")), &req.Variables)
+		json.Unmarshal([]byte(q.Get("extensions")), &req.Extensions)
+	} else {
+		json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, r.Method+" query="+req.Query)
+
+	if pq, ok := req.Extensions["persistedQuery"].(map[string]interface{}); ok {
+		hash := pq["sha256Hash"].(string)
+		if req.Query == "" {
+			if req.Query, ok = s.cache[hash]; !ok {
+				w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+				return
+			}
+		} else {
+			sum := sha256.Sum256([]byte(req.Query))
+			if hex.EncodeToString(sum[:]) != hash {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors":[{"message":"provided sha does not match query"}]}`))
+				return
+			}
+			s.cache[hash] = req.Query
+		}
+	}
+
+	switch {
+	case r.Header.Get("Authorization") != "Bearer t":
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"message":"not logged in","extensions":{"code":"UNAUTHENTICATED"}}]}`))
+	case strings.Contains(req.Query, "broken"):
+		w.Write([]byte(`{"data":{"hello":"partial","user":null},"errors":[` +
+			`{"message":"user not found","path":["user"],"locations":[{"line":1,"column":9}]},` +
+			`{"message":"second"}]}`))
+	case strings.Contains(req.Query, "hello"):
+		name, _ := req.Variables["name"].(string)
+		w.Write([]byte(`{"data":{"hello":"hi ` + name + `"}}`))
+	default:
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("bad gateway"))
+	}
+}
+
+func newGraphQLServer() (*graphqlServer, *httptest.Server) {
+	s := &graphqlServer{cache: map[string]string{}}
+	return s, httptest.NewServer(s)
+}
+
+func authorized() *HttpClient.Request {
+	return HttpClient.NewRequest().AddHeaders(map[string]string{"Authorization": "Bearer t"})
+}
+
+func TestGraphQL_Query(t *testing.T) {
+	_, server := newGraphQLServer()
+	defer server.Close()
+
+	var data struct {
+		Hello string `json:"hello"`
+	}
+	gql := authorized().GraphQL(server.URL)
+	if err := gql.Query(`query($name: String) { hello(name: $name) }`, map[string]interface{}{"name": "bob"}, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Hello != "hi bob" {
+		t.Fatalf("unexpected data %+v", data)
+	}
+
+	err := gql.Query(`{ hello broken user { id } }`, nil, &data)
+	var gqlErrs HttpClient.GraphQLErrors
+	if !errors.As(err, &gqlErrs) || len(gqlErrs) != 2 {
+		t.Fatalf("expected GraphQLErrors, got %v", err)
+	}
+	if err.Error() != "graphql: user not found (path user) (and 1 more errors)" || gqlErrs[0].Locations[0].Column != 9 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if data.Hello != "partial" {
+		t.Fatalf("partial data was not decoded: %+v", data)
+	}
+
+	err = HttpClient.NewRequest().GraphQL(server.URL).Query(`{ hello }`, nil, nil)
+	if !errors.As(err, &gqlErrs) || gqlErrs[0].Code() != "UNAUTHENTICATED" {
+		t.Fatalf("expected UNAUTHENTICATED, got %v", err)
+	}
+
+	err = gql.Query(`{ other }`, nil, nil)
+	var statusErr *HttpClient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+}
+
+func TestGraphQL_PersistedQueries(t *testing.T) {
+	s, server := newGraphQLServer()
+	defer server.Close()
+
+	query := `query Hello($name: String) { hello(name: $name) }`
+	gql := authorized().GraphQL(server.URL).PersistedQueries(true).UseGETForHashedQueries(true)
+
+	for i := 0; i < 2; i++ {
+		var data map[string]string
+		req := &HttpClient.GraphQLRequest{Query: query, OperationName: "Hello", Variables: map[string]interface{}{"name": "amy"}}
+		if err := gql.Do(req, &data); err != nil {
+			t.Fatal(err)
+		}
+		if data["hello"] != "hi amy" {
+			t.Fatalf("unexpected data %v", data)
+		}
+	}
+
+	expected := []string{"GET query=", "POST query=" + query, "GET query="}
+	if strings.Join(s.requests, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected requests:\n%s", strings.Join(s.requests, "\n"))
+	}
+
+	//只知道 hash 时不会重发
+	sum := sha256.Sum256([]byte(query))
+	var data map[string]string
+	if err := authorized().GraphQL(server.URL).Do(&HttpClient.GraphQLRequest{Hash: hex.EncodeToString(sum[:])}, &data); err != nil || data["hello"] != "hi " {
+		t.Fatalf("unexpected result %v %v", data, err)
+	}
+	err := authorized().GraphQL(server.URL).Do(&HttpClient.GraphQLRequest{Hash: "unknown"}, &data)
+	if !errors.Is(err, HttpClient.ErrPersistedQueryNotFound) {
+		t.Fatalf("expected ErrPersistedQueryNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,496 @@
+package HttpClient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HAR 1.2，见 http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	Url         string         `json:"url"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HttpVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+const redacted = "[REDACTED]"
+
+// 记录经过的请求和响应，导出为 .har 文件，可在浏览器开发者工具中查看
+type HARRecorder struct {
+	mu          sync.Mutex
+	entries     []*HAREntry
+	maxBodySize int
+	redact      map[string]bool
+}
+
+func NewHARRecorder() *HARRecorder {
+	h := &HARRecorder{
+		maxBodySize: 64 * 1024,
+		redact:      map[string]bool{},
+	}
+	return h.Redact("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie")
+}
+
+// 请求体和响应体最多记录的字节数，超出部分截断，0 表示不记录内容
+func (h *HARRecorder) MaxBodySize(n int) *HARRecorder {
+	h.maxBodySize = n
+	return h
+}
+
+// 需要脱敏的请求头、响应头和查询参数，不区分大小写
+func (h *HARRecorder) Redact(headers ...string) *HARRecorder {
+	for _, header := range headers {
+		h.redact[http.CanonicalHeaderKey(header)] = true
+	}
+	return h
+}
+
+func (h *HARRecorder) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return h.roundTrip(next, req)
+		})
+	}
+}
+
+func (h *HARRecorder) HAR() *HAR {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entries := make([]*HAREntry, len(h.entries))
+	for i, entry := range h.entries {
+		cp := *entry
+		entries[i] = &cp
+	}
+
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "goutils.HttpClient", Version: "1.0"},
+		Entries: entries,
+	}}
+}
+
+func (h *HARRecorder) Write(w io.Writer) error {
+	b, err := json.MarshalIndent(h.HAR(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (h *HARRecorder) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := h.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *HARRecorder) Reset() {
+	h.mu.Lock()
+	h.entries = nil
+	h.mu.Unlock()
+}
+
+type harTrace struct {
+	mu                                                  sync.Mutex
+	start, dnsStart, dnsDone, connectStart, connectDone time.Time
+	tlsStart, tlsDone, gotConn, wroteRequest, firstByte time.Time
+	remoteAddr                                          string
+}
+
+// httptrace 的回调可能来自拨号协程
+func (t *harTrace) now(field *time.Time) {
+	t.mu.Lock()
+	*field = time.Now()
+	t.mu.Unlock()
+}
+
+func ms(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() {
+		return -1
+	}
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
+
+func (h *HARRecorder) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	entry := &HAREntry{}
+	trace := &harTrace{start: time.Now()}
+	entry.StartedDateTime = trace.start.Format(time.RFC3339Nano)
+	entry.Request = h.harRequest(req)
+
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { trace.now(&trace.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { trace.now(&trace.dnsDone) },
+		ConnectStart:      func(string, string) { trace.now(&trace.connectStart) },
+		ConnectDone:       func(string, string, error) { trace.now(&trace.connectDone) },
+		TLSHandshakeStart: func() { trace.now(&trace.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { trace.now(&trace.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			trace.now(&trace.gotConn)
+			if info.Conn != nil {
+				trace.mu.Lock()
+				trace.remoteAddr = info.Conn.RemoteAddr().String()
+				trace.mu.Unlock()
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { trace.now(&trace.wroteRequest) },
+		GotFirstResponseByte: func() { trace.now(&trace.firstByte) },
+	}))
+
+	h.mu.Lock()
+	h.entries = append(h.entries, entry)
+	h.mu.Unlock()
+
+	res, err := next.RoundTrip(req)
+	if err != nil {
+		h.finish(entry, trace, time.Now())
+		h.mu.Lock()
+		entry.Comment = err.Error()
+		h.mu.Unlock()
+		return res, err
+	}
+
+	h.mu.Lock()
+	entry.Response = h.harResponse(res)
+	h.mu.Unlock()
+
+	res.Body = &harBody{
+		body:     res.Body,
+		recorder: h,
+		entry:    entry,
+		trace:    trace,
+		limit:    h.maxBodySize,
+		encoded:  res.Header.Get("Content-Encoding") != "" || !isTextMime(entry.Response.Content.MimeType),
+	}
+
+	return res, nil
+}
+
+func (h *HARRecorder) finish(entry *HAREntry, trace *harTrace, end time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	begin := trace.start
+	if !trace.dnsStart.IsZero() {
+		begin = trace.dnsStart
+	} else if !trace.connectStart.IsZero() {
+		begin = trace.connectStart
+	} else if !trace.gotConn.IsZero() {
+		begin = trace.gotConn
+	}
+
+	t := HARTimings{
+		Blocked: ms(trace.start, begin),
+		DNS:     ms(trace.dnsStart, trace.dnsDone),
+		Connect: ms(trace.connectStart, trace.connectDone),
+		SSL:     ms(trace.tlsStart, trace.tlsDone),
+		Send:    ms(trace.gotConn, trace.wroteRequest),
+		Wait:    ms(trace.wroteRequest, trace.firstByte),
+		Receive: ms(trace.firstByte, end),
+	}
+	if t.Connect >= 0 && t.SSL >= 0 {
+		//HAR 中 connect 包含 ssl 时间
+		t.Connect += t.SSL
+	}
+	for _, v := range []*float64{&t.Send, &t.Wait, &t.Receive} {
+		if *v < 0 {
+			*v = 0
+		}
+	}
+
+	entry.Timings = t
+	entry.Time = 0
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			entry.Time += v
+		}
+	}
+	entry.ServerIPAddress = hostOf(trace.remoteAddr)
+}
+
+func hostOf(addr string) string {
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		return strings.Trim(addr[:i], "[]")
+	}
+	return addr
+}
+
+func (h *HARRecorder) headers(header http.Header) []HARNameValue {
+	list := make([]HARNameValue, 0)
+	for _, k := range sortedHeaderKeys(header) {
+		for _, v := range header[k] {
+			if h.redact[k] {
+				v = redacted
+			}
+			list = append(list, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return list
+}
+
+func sortedHeaderKeys(header http.Header) []string {
+	data := Data{}
+	for k := range header {
+		data[k] = nil
+	}
+	return sortedKeys(data)
+}
+
+// 查询参数中的 token 等同样需要脱敏
+func (h *HARRecorder) redactUrl(u *url.URL) string {
+	query := u.Query()
+	found := false
+	for k, values := range query {
+		if h.redact[http.CanonicalHeaderKey(k)] {
+			for i := range values {
+				values[i] = redacted
+			}
+			found = true
+		}
+	}
+	if !found {
+		return u.String()
+	}
+
+	cp := *u
+	cp.RawQuery = query.Encode()
+	return cp.String()
+}
+
+func (h *HARRecorder) harRequest(req *http.Request) HARRequest {
+	r := HARRequest{
+		Method:      req.Method,
+		Url:         h.redactUrl(req.URL),
+		HttpVersion: req.Proto,
+		Cookies:     make([]HARNameValue, 0),
+		Headers:     h.headers(req.Header),
+		QueryString: make([]HARNameValue, 0),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	if r.HttpVersion == "" {
+		r.HttpVersion = "HTTP/1.1"
+	}
+
+	for _, c := range req.Cookies() {
+		v := c.Value
+		if h.redact["Cookie"] {
+			v = redacted
+		}
+		r.Cookies = append(r.Cookies, HARNameValue{Name: c.Name, Value: v})
+	}
+
+	query := req.URL.Query()
+	for _, k := range sortedHeaderKeys(http.Header(query)) {
+		for _, v := range query[k] {
+			if h.redact[http.CanonicalHeaderKey(k)] {
+				v = redacted
+			}
+			r.QueryString = append(r.QueryString, HARNameValue{Name: k, Value: v})
+		}
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return r
+	}
+
+	b, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		r.BodySize = -1
+		return r
+	}
+
+	r.BodySize = int64(len(b))
+	r.PostData = &HARPostData{MimeType: req.Header.Get("Content-Type")}
+	if len(b) > h.maxBodySize {
+		b = b[:h.maxBodySize]
+		r.PostData.Comment = "truncated"
+	}
+	r.PostData.Text = string(b)
+
+	return r
+}
+
+func (h *HARRecorder) harResponse(res *http.Response) HARResponse {
+	r := HARResponse{
+		Status:      res.StatusCode,
+		HttpVersion: res.Proto,
+		Cookies:     make([]HARNameValue, 0),
+		Headers:     h.headers(res.Header),
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	r.StatusText = http.StatusText(res.StatusCode)
+	if len(res.Status) > 4 {
+		r.StatusText = res.Status[4:]
+	}
+
+	for _, c := range res.Cookies() {
+		v := c.Value
+		if h.redact["Set-Cookie"] {
+			v = redacted
+		}
+		r.Cookies = append(r.Cookies, HARNameValue{Name: c.Name, Value: v})
+	}
+
+	r.Content.MimeType = res.Header.Get("Content-Type")
+	return r
+}
+
+func isTextMime(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType == ""
+	}
+	return strings.HasPrefix(mt, "text/") || strings.HasSuffix(mt, "json") || strings.HasSuffix(mt, "xml") ||
+		strings.HasSuffix(mt, "javascript") || mt == "application/x-www-form-urlencoded" || mt == "text/event-stream"
+}
+
+// 记录响应体，读完或关闭时补全耗时和大小
+type harBody struct {
+	body     io.ReadCloser
+	recorder *HARRecorder
+	entry    *HAREntry
+	trace    *harTrace
+	limit    int
+	encoded  bool
+	buf      bytes.Buffer
+	size     int64
+	once     sync.Once
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.size += int64(n)
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.buf.Write(p[:room])
+	}
+	if err == io.EOF {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.body.Close()
+	b.done()
+	return err
+}
+
+func (b *harBody) done() {
+	b.once.Do(func() {
+		b.recorder.finish(b.entry, b.trace, time.Now())
+
+		b.recorder.mu.Lock()
+		defer b.recorder.mu.Unlock()
+		content := &b.entry.Response.Content
+		content.Size = b.size
+		b.entry.Response.BodySize = b.size
+		if b.encoded {
+			content.Text = base64.StdEncoding.EncodeToString(b.buf.Bytes())
+			content.Encoding = "base64"
+		} else {
+			content.Text = b.buf.String()
+		}
+		if int64(b.buf.Len()) < b.size {
+			content.Comment = "truncated"
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package HttpClient_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func TestRequest_Use(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Order")))
+	}))
+	defer server.Close()
+
+	tag := func(s string) HttpClient.Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return HttpClient.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Order", req.Header.Get("X-Order")+s)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	resp, err := HttpClient.NewRequest().Use(tag("a"), tag("b")).Use(tag("c")).GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "abc" {
+		t.Fatalf("unexpected middleware order %q", s)
+	}
+}
+
+func TestHARRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "secret"})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1,"name":"goutils"}`))
+	}))
+	defer server.Close()
+
+	rec := HttpClient.NewHARRecorder().MaxBodySize(10).Redact("X-Token", "access_token")
+	req := HttpClient.NewRequest().
+		Json().
+		Use(rec.Middleware()).
+		SetBasicAuth("user", "pass").
+		AddHeaders(map[string]string{"X-Token": "t", "X-Id": "1"}).
+		AddCookies(map[string]string{"sid": "s1"})
+
+	resp, err := req.POST(server.URL+"/items?a=1&Access_Token=abc", HttpClient.Data{"name": "goutils"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != `{"id":1,"name":"goutils"}` {
+		t.Fatalf("recorder changed the body: %q", s)
+	}
+
+	har := rec.HAR()
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("unexpected log %+v", har.Log)
+	}
+	entry := har.Log.Entries[0]
+
+	if entry.Request.Method != http.MethodPost || entry.Request.Url != server.URL+"/items?Access_Token=%5BREDACTED%5D&a=1" {
+		t.Fatalf("unexpected request %s %s", entry.Request.Method, entry.Request.Url)
+	}
+	if len(entry.Request.QueryString) != 2 || entry.Request.QueryString[0].Value != "[REDACTED]" || entry.Request.QueryString[1].Value != "1" {
+		t.Fatalf("unexpected query string %+v", entry.Request.QueryString)
+	}
+	for _, h := range entry.Request.Headers {
+		switch h.Name {
+		case "Authorization", "X-Token", "Cookie":
+			if h.Value != "[REDACTED]" {
+				t.Fatalf("header %s was not redacted: %q", h.Name, h.Value)
+			}
+		case "X-Id":
+			if h.Value != "1" {
+				t.Fatalf("unexpected X-Id %q", h.Value)
+			}
+		}
+	}
+	if entry.Request.Cookies[0].Value != "[REDACTED]" || entry.Response.Cookies[0].Value != "[REDACTED]" {
+		t.Fatal("cookies were not redacted")
+	}
+	if entry.Request.BodySize != 18 || entry.Request.PostData.Text != `{"name":"g` || entry.Request.PostData.Comment != "truncated" {
+		t.Fatalf("unexpected post data %d %+v", entry.Request.BodySize, entry.Request.PostData)
+	}
+
+	if entry.Response.Status != http.StatusCreated || entry.Response.StatusText != "Created" {
+		t.Fatalf("unexpected status %d %q", entry.Response.Status, entry.Response.StatusText)
+	}
+	content := entry.Response.Content
+	if content.Size != 25 || content.Text != `{"id":1,"n` || content.MimeType != "application/json" {
+		t.Fatalf("unexpected content %+v", content)
+	}
+	if entry.ServerIPAddress != "127.0.0.1" || entry.Time <= 0 {
+		t.Fatalf("unexpected timing %v %q", entry.Time, entry.ServerIPAddress)
+	}
+
+	f, err := ioutil.TempFile("", "goutils-*.har")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	filename := f.Name()
+	defer os.Remove(filename)
+
+	if err := rec.WriteFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["log"]["version"] != "1.2" || strings.Contains(string(b), "secret") {
+		t.Fatalf("unexpected har file %s", b)
+	}
+
+	rec.Reset()
+	if len(rec.HAR().Log.Entries) != 0 {
+		t.Fatal("Reset did not clear entries")
+	}
+}
+
+func TestHARRecorder_Error(t *testing.T) {
+	rec := HttpClient.NewHARRecorder()
+	_, err := HttpClient.NewRequest().Use(rec.Middleware()).GET("http://127.0.0.1:1/", nil)
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+	entries := rec.HAR().Log.Entries
+	if len(entries) != 1 || entries[0].Comment == "" {
+		t.Fatalf("failed request was not recorded: %+v", entries)
+	}
+}
@@ -0,0 +1,273 @@
+package HttpClient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// 对冲请求：请求在 delay 内没有返回时，再向同一或备用主机发送一份相同的请求，
+// 采用最先成功的响应并取消其余请求，用于降低只读接口的长尾延迟
+type Hedger struct {
+	delay     time.Duration
+	maxHedges int
+	hosts     []*url.URL
+	methods   map[string]bool
+
+	requests uint64
+	hedged   uint64
+	hedges   uint64
+	wins     uint64
+}
+
+type HedgeStats struct {
+	//参与对冲的请求数
+	Requests uint64
+	//发出过对冲请求的请求数
+	Hedged uint64
+	//发出的对冲请求总数
+	Hedges uint64
+	//由对冲请求胜出的请求数
+	Wins uint64
+}
+
+// 对冲请求胜出的比例
+func (s HedgeStats) WinRate() float64 {
+	if s.Hedged == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Hedged)
+}
+
+// delay 为 0 时同时发出所有请求，相当于并行竞速
+func NewHedger(delay time.Duration) *Hedger {
+	return &Hedger{
+		delay:     delay,
+		maxHedges: 1,
+		methods:   map[string]bool{http.MethodGet: true, http.MethodHead: true, http.MethodOptions: true},
+	}
+}
+
+// 最多额外发送的请求数，默认 1
+func (h *Hedger) MaxHedges(n int) *Hedger {
+	h.maxHedges = n
+	return h
+}
+
+// 对冲请求依次发往的备用主机，如 "replica-1:8080" 或 "https://replica-1"，不设置时发往原主机
+func (h *Hedger) Hosts(hosts ...string) *Hedger {
+	h.hosts = h.hosts[:0]
+	for _, host := range hosts {
+		if !strings.Contains(host, "://") {
+			host = "//" + host
+		}
+		if u, err := url.Parse(host); err == nil && u.Host != "" {
+			h.hosts = append(h.hosts, u)
+		}
+	}
+	return h
+}
+
+// 允许对冲的请求方法，默认只有 GET、HEAD、OPTIONS
+func (h *Hedger) Methods(methods ...string) *Hedger {
+	h.methods = map[string]bool{}
+	for _, method := range methods {
+		h.methods[strings.ToUpper(method)] = true
+	}
+	return h
+}
+
+func (h *Hedger) Stats() HedgeStats {
+	return HedgeStats{
+		Requests: atomic.LoadUint64(&h.requests),
+		Hedged:   atomic.LoadUint64(&h.hedged),
+		Hedges:   atomic.LoadUint64(&h.hedges),
+		Wins:     atomic.LoadUint64(&h.wins),
+	}
+}
+
+func (h *Hedger) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return h.roundTrip(next, req)
+		})
+	}
+}
+
+func (h *Hedger) hedgeable(req *http.Request) bool {
+	if !h.methods[req.Method] || h.maxHedges <= 0 {
+		return false
+	}
+	//请求体需要能重复读取
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+type hedgeResult struct {
+	res   *http.Response
+	err   error
+	index int
+}
+
+// 5xx 和网络错误视为失败，会继续等待其他请求
+func (r hedgeResult) ok() bool {
+	return r.err == nil && r.res.StatusCode < 500
+}
+
+func (h *Hedger) attempt(req *http.Request, ctx context.Context, index int) (*http.Request, error) {
+	attempt := req.Clone(ctx)
+	if index == 0 {
+		return attempt, nil
+	}
+
+	if len(h.hosts) > 0 {
+		host := h.hosts[(index-1)%len(h.hosts)]
+		if host.Scheme != "" {
+			attempt.URL.Scheme = host.Scheme
+		}
+		attempt.URL.Host = host.Host
+		attempt.Host = ""
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attempt.Body = body
+	}
+	return attempt, nil
+}
+
+func (h *Hedger) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if !h.hedgeable(req) {
+		return next.RoundTrip(req)
+	}
+	atomic.AddUint64(&h.requests, 1)
+
+	results := make(chan hedgeResult, h.maxHedges+1)
+	cancels := make([]context.CancelFunc, 0, h.maxHedges+1)
+	inflight := 0
+
+	launch := func() error {
+		index := len(cancels)
+		ctx, cancel := context.WithCancel(req.Context())
+		attempt, err := h.attempt(req, ctx, index)
+		if err != nil {
+			cancel()
+			return err
+		}
+		cancels = append(cancels, cancel)
+		inflight++
+		if index == 1 {
+			atomic.AddUint64(&h.hedged, 1)
+		}
+		if index > 0 {
+			atomic.AddUint64(&h.hedges, 1)
+		}
+		go func() {
+			res, err := next.RoundTrip(attempt)
+			results <- hedgeResult{res: res, err: err, index: index}
+		}()
+		return nil
+	}
+
+	//其余请求取消后仍可能返回响应，需要关闭响应体
+	abandon := func(keep int) {
+		for i, cancel := range cancels {
+			if i != keep {
+				cancel()
+			}
+		}
+		go func(n int) {
+			for ; n > 0; n-- {
+				if r := <-results; r.res != nil {
+					r.res.Body.Close()
+				}
+			}
+		}(inflight)
+	}
+
+	if err := launch(); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(h.delay)
+	defer timer.Stop()
+
+	var last *hedgeResult
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) <= h.maxHedges {
+				if err := launch(); err != nil {
+					abandon(-1)
+					return nil, err
+				}
+				if len(cancels) <= h.maxHedges {
+					timer.Reset(h.delay)
+				}
+			}
+
+		case r := <-results:
+			inflight--
+			if r.ok() {
+				if r.index > 0 {
+					atomic.AddUint64(&h.wins, 1)
+				}
+				if last != nil && last.res != nil {
+					last.res.Body.Close()
+				}
+				abandon(r.index)
+				r.res.Body = &cancelOnClose{ReadCloser: r.res.Body, cancel: cancels[r.index]}
+				return r.res, nil
+			}
+
+			if last != nil {
+				if last.res != nil {
+					last.res.Body.Close()
+				}
+				cancels[last.index]()
+			}
+			last = &r
+
+			if inflight > 0 {
+				continue
+			}
+			//都失败了，还有名额时立即补发
+			if len(cancels) <= h.maxHedges {
+				if err := launch(); err == nil {
+					continue
+				}
+			}
+			abandon(r.index)
+			if r.err != nil {
+				cancels[r.index]()
+				return nil, r.err
+			}
+			r.res.Body = &cancelOnClose{ReadCloser: r.res.Body, cancel: cancels[r.index]}
+			return r.res, nil
+
+		case <-req.Context().Done():
+			abandon(-1)
+			if last != nil && last.res != nil {
+				last.res.Body.Close()
+			}
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// 响应体关闭时才取消对应请求的 context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
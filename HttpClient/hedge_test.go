@@ -0,0 +1,135 @@
+package HttpClient_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func TestHedger_HedgeWins(t *testing.T) {
+	var hits, canceled int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				atomic.AddInt32(&canceled, 1)
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	hedger := HttpClient.NewHedger(50 * time.Millisecond)
+	start := time.Now()
+	resp, err := HttpClient.NewRequest().Use(hedger.Middleware()).GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "ok" {
+		t.Fatalf("unexpected body %q", s)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("hedged request took %v", elapsed)
+	}
+
+	stats := hedger.Stats()
+	if stats.Requests != 1 || stats.Hedged != 1 || stats.Hedges != 1 || stats.Wins != 1 || stats.WinRate() != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&canceled) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&canceled) != 1 {
+		t.Fatal("the slow request was not canceled")
+	}
+}
+
+func TestHedger_NoHedgeWhenFast(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(r.Method))
+	}))
+	defer server.Close()
+
+	hedger := HttpClient.NewHedger(200 * time.Millisecond)
+	req := HttpClient.NewRequest().Use(hedger.Middleware())
+	for i := 0; i < 3; i++ {
+		if _, err := req.GET(server.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//POST 默认不对冲
+	if _, err := req.POST(server.URL, HttpClient.Data{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := hedger.Stats()
+	if stats.Requests != 3 || stats.Hedged != 0 || stats.Wins != 0 || atomic.LoadInt32(&hits) != 4 {
+		t.Fatalf("unexpected stats %+v hits=%d", stats, hits)
+	}
+}
+
+func TestHedger_AlternateHost(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+			w.Write([]byte("primary"))
+		}
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("replica " + r.URL.RequestURI()))
+	}))
+	defer replica.Close()
+
+	hedger := HttpClient.NewHedger(20 * time.Millisecond).Hosts(replica.URL)
+	resp, err := HttpClient.NewRequest().Use(hedger.Middleware()).GET(primary.URL+"/items", HttpClient.Data{"id": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "replica /items?id=1" {
+		t.Fatalf("unexpected body %q", s)
+	}
+}
+
+func TestHedger_Failures(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		if strings.HasSuffix(r.URL.Path, "/down") || n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("third"))
+	}))
+	defer server.Close()
+
+	//失败后立即补发，不必等待 delay
+	hedger := HttpClient.NewHedger(time.Minute).MaxHedges(2)
+	resp, err := HttpClient.NewRequest().Use(hedger.Middleware()).GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "third" || hedger.Stats().Hedges != 2 || hedger.Stats().Wins != 1 {
+		t.Fatalf("unexpected result %q %+v", s, hedger.Stats())
+	}
+
+	resp, err = HttpClient.NewRequest().Use(hedger.Middleware()).GET(server.URL+"/down", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("expected the last failure, got %d", resp.StatusCode())
+	}
+}
@@ -0,0 +1,243 @@
+package HttpClient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// JSON-RPC 2.0 预定义的错误码
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCInternalError  = -32603
+)
+
+// 响应中的 error 对象
+type RPCError struct {
+	Code    int                 `json:"code"`
+	Message string              `json:"message"`
+	Data    jsoniter.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("jsonrpc: %s (code %d, data %s)", e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("jsonrpc: %s (code %d)", e.Message, e.Code)
+}
+
+// 将 error.data 解析到 v
+func (e *RPCError) DecodeData(v interface{}) error {
+	if len(e.Data) == 0 {
+		return errors.New("jsonrpc: error has no data")
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+type rpcRequest struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+	Id      *uint64     `json:"id,omitempty"`
+}
+
+type rpcResponse struct {
+	Jsonrpc string              `json:"jsonrpc"`
+	Result  jsoniter.RawMessage `json:"result"`
+	Error   *RPCError           `json:"error"`
+	Id      jsoniter.RawMessage `json:"id"`
+}
+
+func (r *rpcResponse) id() string {
+	id, err := strconv.Unquote(string(r.Id))
+	if err != nil {
+		return string(r.Id)
+	}
+	return id
+}
+
+type JSONRPC struct {
+	request *Request
+	url     string
+	id      uint64
+}
+
+// 使用当前 Request 的配置调用 JSON-RPC 2.0 服务，请求 id 自增
+func (r *Request) JSONRPC(url string) *JSONRPC {
+	return &JSONRPC{request: r, url: url}
+}
+
+func (c *JSONRPC) nextId() *uint64 {
+	id := atomic.AddUint64(&c.id, 1)
+	return &id
+}
+
+// 调用 method 并将 result 解析到 result，服务端返回 error 对象时返回 *RPCError
+func (c *JSONRPC) Call(method string, params interface{}, result interface{}) error {
+	req := &rpcRequest{Jsonrpc: "2.0", Method: method, Params: params, Id: c.nextId()}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	body, err := c.post(b)
+	if err != nil {
+		return err
+	}
+
+	res := &rpcResponse{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return &DecodeError{Err: err, Body: body}
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.id() != strconv.FormatUint(*req.Id, 10) {
+		return fmt.Errorf("jsonrpc: response id %s does not match request id %d", res.Id, *req.Id)
+	}
+	if result != nil && len(res.Result) > 0 {
+		if err := json.Unmarshal(res.Result, result); err != nil {
+			return &DecodeError{Err: err, Body: res.Result}
+		}
+	}
+	return nil
+}
+
+// 发送通知，不带 id，服务端不会返回结果
+func (c *JSONRPC) Notify(method string, params interface{}) error {
+	b, err := json.Marshal(&rpcRequest{Jsonrpc: "2.0", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	_, err = c.post(b)
+	return err
+}
+
+func (c *JSONRPC) post(b []byte) ([]byte, error) {
+	resp, err := c.request.sendJSON(http.MethodPost, c.url, b)
+	if err != nil {
+		return nil, err
+	}
+	body, err := resp.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	//部分服务端出错时返回 4xx/5xx 并附带 error 对象，交给调用方解析
+	if resp.StatusCode() < 200 || resp.StatusCode() > 299 {
+		res := &rpcResponse{}
+		if json.Unmarshal(body, res) == nil && res.Error != nil {
+			return body, nil
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode(), Status: resp.Resp.Status, Body: body, Response: resp}
+	}
+	return body, nil
+}
+
+// 泛型版本的 Call
+func CallRPC[T any](c *JSONRPC, method string, params interface{}) (T, error) {
+	var v T
+	err := c.Call(method, params, &v)
+	return v, err
+}
+
+// 批量调用中的一项，Send 之后可读取结果
+type RPCCall struct {
+	Method string
+	Params interface{}
+	Error  error
+	id     *uint64
+	result interface{}
+	done   bool
+}
+
+type RPCBatch struct {
+	client *JSONRPC
+	calls  []*RPCCall
+}
+
+// 创建批量调用，在一个 HTTP 请求中发送多个调用和通知
+func (c *JSONRPC) Batch() *RPCBatch {
+	return &RPCBatch{client: c}
+}
+
+func (b *RPCBatch) Call(method string, params interface{}, result interface{}) *RPCCall {
+	call := &RPCCall{Method: method, Params: params, id: b.client.nextId(), result: result}
+	b.calls = append(b.calls, call)
+	return call
+}
+
+func (b *RPCBatch) Notify(method string, params interface{}) *RPCBatch {
+	b.calls = append(b.calls, &RPCCall{Method: method, Params: params})
+	return b
+}
+
+// 发送批量请求，返回的错误只表示整个批次失败，单个调用的错误记录在 RPCCall.Error 中
+func (b *RPCBatch) Send() error {
+	if len(b.calls) == 0 {
+		return errors.New("jsonrpc: empty batch")
+	}
+
+	reqs := make([]*rpcRequest, len(b.calls))
+	calls := map[string]*RPCCall{}
+	for i, call := range b.calls {
+		reqs[i] = &rpcRequest{Jsonrpc: "2.0", Method: call.Method, Params: call.Params, Id: call.id}
+		if call.id != nil {
+			calls[strconv.FormatUint(*call.id, 10)] = call
+		}
+	}
+
+	data, err := json.Marshal(reqs)
+	if err != nil {
+		return err
+	}
+	body, err := b.client.post(data)
+	if err != nil {
+		return err
+	}
+
+	//只有通知时服务端不返回任何内容
+	if len(calls) == 0 {
+		return nil
+	}
+
+	//请求整体无效时服务端返回单个 error 对象
+	single := &rpcResponse{}
+	if json.Unmarshal(body, single) == nil && single.Error != nil {
+		return single.Error
+	}
+
+	responses := make([]*rpcResponse, 0)
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return &DecodeError{Err: err, Body: body}
+	}
+
+	for _, res := range responses {
+		call, ok := calls[res.id()]
+		if !ok {
+			continue
+		}
+		call.done = true
+		if res.Error != nil {
+			call.Error = res.Error
+		} else if call.result != nil && len(res.Result) > 0 {
+			if err := json.Unmarshal(res.Result, call.result); err != nil {
+				call.Error = &DecodeError{Err: err, Body: res.Result}
+			}
+		}
+	}
+
+	for _, call := range calls {
+		if !call.done {
+			call.Error = fmt.Errorf("jsonrpc: no response for %s (id %d)", call.Method, *call.id)
+		}
+	}
+	return nil
+}
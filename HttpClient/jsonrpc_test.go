@@ -0,0 +1,220 @@
+package HttpClient_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+type rpcServer struct {
+	mu            sync.Mutex
+	notifications []string
+}
+
+type rpcMessage struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Id      json.RawMessage `json:"id"`
+}
+
+func (s *rpcServer) handle(msg *rpcMessage) map[string]interface{} {
+	if msg.Id == nil {
+		s.mu.Lock()
+		s.notifications = append(s.notifications, msg.Method+string(msg.Params))
+		s.mu.Unlock()
+		return nil
+	}
+
+	res := map[string]interface{}{"jsonrpc": "2.0", "id": msg.Id}
+	switch msg.Method {
+	case "add":
+		var args []int
+		if err := json.Unmarshal(msg.Params, &args); err != nil {
+			res["error"] = map[string]interface{}{"code": -32602, "message": "Invalid params"}
+			break
+		}
+		sum := 0
+		for _, v := range args {
+			sum += v
+		}
+		res["result"] = sum
+	case "user":
+		res["result"] = map[string]interface{}{"id": 1, "name": "goutils"}
+	case "fail":
+		res["error"] = map[string]interface{}{"code": 1001, "message": "quota exceeded", "data": map[string]int{"retryAfter": 30}}
+	default:
+		res["error"] = map[string]interface{}{"code": -32601, "message": "Method not found"}
+	}
+	return res
+}
+
+func (s *rpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	if r.Header.Get("Content-Type") != "application/json;charset=utf-8" {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []*rpcMessage
+		json.Unmarshal(body, &batch)
+		if len(batch) == 0 {
+			json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": nil,
+				"error": map[string]interface{}{"code": -32600, "message": "Invalid Request"}})
+			return
+		}
+		responses := make([]map[string]interface{}, 0)
+		//倒序返回，客户端需按 id 匹配
+		for i := len(batch) - 1; i >= 0; i-- {
+			if res := s.handle(batch[i]); res != nil {
+				responses = append(responses, res)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	msg := &rpcMessage{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": nil,
+			"error": map[string]interface{}{"code": -32700, "message": "Parse error"}})
+		return
+	}
+	if res := s.handle(msg); res != nil {
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestJSONRPC_Call(t *testing.T) {
+	s := &rpcServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	rpc := HttpClient.NewRequest().JSONRPC(server.URL)
+
+	var sum int
+	if err := rpc.Call("add", []int{1, 2, 3}, &sum); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Fatalf("unexpected sum %d", sum)
+	}
+
+	u, err := HttpClient.CallRPC[user](rpc, "user", nil)
+	if err != nil || u.Name != "goutils" {
+		t.Fatalf("unexpected user %+v %v", u, err)
+	}
+
+	err = rpc.Call("fail", nil, nil)
+	var rpcErr *HttpClient.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != 1001 || rpcErr.Message != "quota exceeded" {
+		t.Fatalf("expected RPCError, got %v", err)
+	}
+	var data struct {
+		RetryAfter int `json:"retryAfter"`
+	}
+	if err := rpcErr.DecodeData(&data); err != nil || data.RetryAfter != 30 {
+		t.Fatalf("unexpected error data %+v %v", data, err)
+	}
+
+	err = rpc.Call("missing", nil, nil)
+	if !errors.As(err, &rpcErr) || rpcErr.Code != HttpClient.RPCMethodNotFound {
+		t.Fatalf("expected method not found, got %v", err)
+	}
+
+	if err := rpc.Notify("log", map[string]string{"msg": "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.notifications) != 1 || s.notifications[0] != `log{"msg":"hi"}` {
+		t.Fatalf("unexpected notifications %v", s.notifications)
+	}
+}
+
+func TestJSONRPC_Batch(t *testing.T) {
+	s := &rpcServer{}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	rpc := HttpClient.NewRequest().JSONRPC(server.URL)
+
+	var a, b int
+	var u user
+	batch := rpc.Batch()
+	callA := batch.Call("add", []int{1, 1}, &a)
+	callB := batch.Call("add", []int{2, 3}, &b)
+	callFail := batch.Call("fail", nil, nil)
+	batch.Notify("log", []string{"batch"})
+	callUser := batch.Call("user", nil, &u)
+
+	if err := batch.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if callA.Error != nil || callB.Error != nil || callUser.Error != nil || a != 2 || b != 5 || u.Id != 1 {
+		t.Fatalf("unexpected results a=%d b=%d u=%+v", a, b, u)
+	}
+	var rpcErr *HttpClient.RPCError
+	if !errors.As(callFail.Error, &rpcErr) || rpcErr.Code != 1001 {
+		t.Fatalf("expected RPCError, got %v", callFail.Error)
+	}
+	if len(s.notifications) != 1 || s.notifications[0] != `log["batch"]` {
+		t.Fatalf("unexpected notifications %v", s.notifications)
+	}
+
+	if err := rpc.Batch().Notify("log", nil).Notify("log", nil).Send(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.notifications) != 3 {
+		t.Fatalf("unexpected notifications %v", s.notifications)
+	}
+
+	if err := rpc.Batch().Send(); err == nil {
+		t.Fatal("expected error for empty batch")
+	}
+}
+
+func TestJSONRPC_HttpError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := HttpClient.NewRequest().JSONRPC(server.URL).Call("add", []int{1}, nil)
+	var statusErr *HttpClient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+}
+
+func TestJSONRPC_Parallel(t *testing.T) {
+	server := httptest.NewServer(&rpcServer{})
+	defer server.Close()
+
+	rpc := HttpClient.NewRequest().JSONRPC(server.URL)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sum, err := HttpClient.CallRPC[int](rpc, "add", []int{i, 1})
+			if err != nil || sum != i+1 {
+				t.Errorf("unexpected sum %d %v", sum, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,20 @@
+package HttpClient
+
+import "net/http"
+
+// 包装底层 Transport 的中间件，可用于记录、重试、改写请求等
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// 按添加顺序由外向内包装 Transport
+func (r *Request) Use(middlewares ...Middleware) *Request {
+	r.middlewares = append(r.middlewares, middlewares...)
+	//已创建的 client 需要按新的中间件重建
+	r.resetClient()
+	return r
+}
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,388 @@
+package HttpClient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// 分页策略，根据上一页的响应计算下一页的地址和参数，没有下一页时返回 false
+type PageStrategy interface {
+	First(reqUrl string, data Data) (string, Data)
+	Next(reqUrl string, data Data, resp *Response, items int) (string, Data, bool, error)
+}
+
+type Paginator struct {
+	req       *Request
+	strategy  PageStrategy
+	url       string
+	data      Data
+	itemsPath []string
+	maxPages  int
+
+	pages int
+	done  bool
+	resp  *Response
+	items []jsoniter.RawMessage
+	index int
+	err   error
+}
+
+// 按策略惰性翻页，遇到空页或没有下一页时停止
+func (r *Request) Paginate(reqUrl string, data Data, strategy PageStrategy) *Paginator {
+	return &Paginator{
+		req:      r,
+		strategy: strategy,
+		url:      reqUrl,
+		data:     data,
+		index:    -1,
+	}
+}
+
+// 列表在响应json中的路径，为空表示响应本身就是数组
+func (p *Paginator) Items(path ...string) *Paginator {
+	p.itemsPath = path
+	return p
+}
+
+func (p *Paginator) MaxPages(n int) *Paginator {
+	p.maxPages = n
+	return p
+}
+
+func (p *Paginator) NextPage() bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	if p.maxPages > 0 && p.pages >= p.maxPages {
+		p.done = true
+		return false
+	}
+
+	reqUrl, data := p.url, p.data
+	if p.pages == 0 {
+		reqUrl, data = p.strategy.First(reqUrl, data)
+	} else {
+		var ok bool
+		var err error
+		reqUrl, data, ok, err = p.strategy.Next(reqUrl, data, p.resp, len(p.items))
+		if err != nil {
+			p.err = err
+			return false
+		}
+		if !ok {
+			p.done = true
+			return false
+		}
+	}
+
+	pageUrl, err := encodePageUrl(reqUrl, data)
+	if err != nil {
+		p.err = err
+		return false
+	}
+	//地址已经编码完成，不再经过 buildUrl 解析查询参数，避免游标中的 = 等字符被截断
+	resp, err := p.req.requestBody(http.MethodGet, pageUrl, p.req.headers, nil)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 299 {
+		resp.Close()
+		p.err = fmt.Errorf("paginate %s: unexpected status code %d", reqUrl, resp.StatusCode())
+		return false
+	}
+
+	b, err := resp.Body()
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	items, err := decodeItems(b, p.itemsPath)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	p.url, p.data = reqUrl, data
+	p.pages++
+	p.resp = resp
+	p.items = items
+	p.index = -1
+
+	if len(items) == 0 {
+		p.done = true
+		return false
+	}
+
+	return true
+}
+
+func (p *Paginator) Next() bool {
+	for p.index+1 >= len(p.items) {
+		if !p.NextPage() {
+			return false
+		}
+	}
+
+	p.index++
+	return true
+}
+
+func (p *Paginator) Decode(v interface{}) error {
+	if p.index < 0 || p.index >= len(p.items) {
+		return fmt.Errorf("paginate: no current item")
+	}
+	return json.Unmarshal(p.items[p.index], v)
+}
+
+func (p *Paginator) PageItems() []jsoniter.RawMessage {
+	return p.items
+}
+
+func (p *Paginator) Response() *Response {
+	return p.resp
+}
+
+func (p *Paginator) Pages() int {
+	return p.pages
+}
+
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+func decodeItems(b []byte, path []string) ([]jsoniter.RawMessage, error) {
+	raw := jsoniter.RawMessage(b)
+	for _, key := range path {
+		obj := map[string]jsoniter.RawMessage{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		raw = obj[key]
+		if raw == nil {
+			return nil, nil
+		}
+	}
+
+	items := make([]jsoniter.RawMessage, 0)
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// 用 url.Values 拼接分页参数，游标等任意字符串都会被正确编码
+func encodePageUrl(reqUrl string, data Data) (string, error) {
+	if len(data) == 0 {
+		return reqUrl, nil
+	}
+
+	u, err := url.Parse(reqUrl)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	for k, v := range data {
+		s, ok := v.(string)
+		if !ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			s = string(b)
+		}
+		query.Set(k, s)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func copyData(data Data) Data {
+	d := Data{}
+	for k, v := range data {
+		d[k] = v
+	}
+	return d
+}
+
+type pageNumberStrategy struct {
+	param string
+	start int
+}
+
+// 页码分页：?page=1, ?page=2 ...
+func PageNumber(param string, start int) PageStrategy {
+	return &pageNumberStrategy{param: param, start: start}
+}
+
+func (s *pageNumberStrategy) First(reqUrl string, data Data) (string, Data) {
+	d := copyData(data)
+	d[s.param] = s.start
+	return reqUrl, d
+}
+
+func (s *pageNumberStrategy) Next(reqUrl string, data Data, resp *Response, items int) (string, Data, bool, error) {
+	page, err := intParam(data, s.param)
+	if err != nil {
+		return "", nil, false, err
+	}
+	d := copyData(data)
+	d[s.param] = page + 1
+	return reqUrl, d, true, nil
+}
+
+type offsetStrategy struct {
+	offsetParam string
+	limitParam  string
+	limit       int
+}
+
+// 偏移分页：?offset=0&limit=20, ?offset=20&limit=20 ...
+func Offset(offsetParam, limitParam string, limit int) PageStrategy {
+	return &offsetStrategy{offsetParam: offsetParam, limitParam: limitParam, limit: limit}
+}
+
+func (s *offsetStrategy) First(reqUrl string, data Data) (string, Data) {
+	d := copyData(data)
+	d[s.offsetParam] = 0
+	d[s.limitParam] = s.limit
+	return reqUrl, d
+}
+
+func (s *offsetStrategy) Next(reqUrl string, data Data, resp *Response, items int) (string, Data, bool, error) {
+	offset, err := intParam(data, s.offsetParam)
+	if err != nil {
+		return "", nil, false, err
+	}
+	d := copyData(data)
+	d[s.offsetParam] = offset + items
+	return reqUrl, d, true, nil
+}
+
+func intParam(data Data, param string) (int, error) {
+	n, ok := data[param].(int)
+	if !ok {
+		return 0, fmt.Errorf("paginate: parameter %q is %T, not int", param, data[param])
+	}
+	return n, nil
+}
+
+type cursorStrategy struct {
+	param string
+	path  []interface{}
+}
+
+// 游标分页：从响应json的path中读取下一页游标，作为param参数传递，游标为空时停止
+func Cursor(param string, path ...string) PageStrategy {
+	s := &cursorStrategy{param: param}
+	for _, key := range path {
+		s.path = append(s.path, key)
+	}
+	return s
+}
+
+func (s *cursorStrategy) First(reqUrl string, data Data) (string, Data) {
+	return reqUrl, copyData(data)
+}
+
+func (s *cursorStrategy) Next(reqUrl string, data Data, resp *Response, items int) (string, Data, bool, error) {
+	b, err := resp.Body()
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	cursor := json.Get(b, s.path...).ToString()
+	if cursor == "" {
+		return "", nil, false, nil
+	}
+
+	d := copyData(data)
+	d[s.param] = cursor
+	return reqUrl, d, true, nil
+}
+
+type linkHeaderStrategy struct{}
+
+// RFC 5988 Link 头分页，跟随 rel="next" 直到不存在
+func LinkHeader() PageStrategy {
+	return &linkHeaderStrategy{}
+}
+
+func (s *linkHeaderStrategy) First(reqUrl string, data Data) (string, Data) {
+	return reqUrl, data
+}
+
+func (s *linkHeaderStrategy) Next(reqUrl string, data Data, resp *Response, items int) (string, Data, bool, error) {
+	next, ok := ParseLinkHeader(resp.Headers()["Link"])["next"]
+	if !ok {
+		return "", nil, false, nil
+	}
+
+	base, err := url.Parse(reqUrl)
+	if err != nil {
+		return "", nil, false, err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	//下一页地址已包含全部参数
+	return base.ResolveReference(ref).String(), nil, true, nil
+}
+
+// 解析 Link 头，返回 rel => url
+func ParseLinkHeader(headers []string) map[string]string {
+	links := map[string]string{}
+	for _, header := range headers {
+		for _, link := range splitLink(header, ',') {
+			parts := splitLink(link, ';')
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) != 2 || strings.ToLower(kv[0]) != "rel" {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(kv[1], `"`)) {
+					links[strings.ToLower(rel)] = target
+				}
+			}
+		}
+	}
+
+	return links
+}
+
+// 按 sep 分割，忽略 <...> 和引号内的分隔符，地址和 title 中都可能出现逗号
+func splitLink(s string, sep rune) []string {
+	parts := make([]string, 0)
+	inUrl, inQuote := false, false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '<' && !inQuote:
+			inUrl = true
+		case c == '>' && !inQuote:
+			inUrl = false
+		case c == '"' && !inUrl:
+			inQuote = !inQuote
+		case c == sep && !inUrl && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,204 @@
+package HttpClient_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+var pageItems = []int{1, 2, 3, 4, 5, 6, 7}
+
+func pageSlice(offset, limit int) []int {
+	if offset >= len(pageItems) {
+		return []int{}
+	}
+	end := offset + limit
+	if end > len(pageItems) {
+		end = len(pageItems)
+	}
+	return pageItems[offset:end]
+}
+
+func collect(t *testing.T, p *HttpClient.Paginator) []int {
+	got := make([]int, 0)
+	for p.Next() {
+		var v int
+		if err := p.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestPaginator_PageNumber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		items, _ := HttpClient.Json().Marshal(pageSlice((page-1)*3, 3))
+		fmt.Fprintf(w, `{"data":{"list":%s}}`, items)
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL, nil, HttpClient.PageNumber("page", 1)).Items("data", "list")
+	got := collect(t, p)
+	if fmt.Sprint(got) != fmt.Sprint(pageItems) {
+		t.Fatalf("got %v", got)
+	}
+	if p.Pages() != 4 {
+		t.Fatalf("expected 4 page requests, got %d", p.Pages())
+	}
+}
+
+func TestPaginator_Offset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		HttpClient.Json().NewEncoder(w).Encode(pageSlice(offset, limit))
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL, nil, HttpClient.Offset("offset", "limit", 5)).MaxPages(1)
+	got := collect(t, p)
+	if fmt.Sprint(got) != "[1 2 3 4 5]" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestPaginator_Cursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		next := ""
+		if offset+3 < len(pageItems) {
+			next = strconv.Itoa(offset + 3)
+		}
+		items, _ := HttpClient.Json().Marshal(pageSlice(offset, 3))
+		fmt.Fprintf(w, `{"items":%s,"meta":{"next_cursor":"%s"}}`, items, next)
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL, HttpClient.Data{"q": "x"}, HttpClient.Cursor("cursor", "meta", "next_cursor")).Items("items")
+	got := collect(t, p)
+	if fmt.Sprint(got) != fmt.Sprint(pageItems) {
+		t.Fatalf("got %v", got)
+	}
+	if p.Pages() != 3 {
+		t.Fatalf("expected 3 page requests, got %d", p.Pages())
+	}
+}
+
+func TestPaginator_CursorEncoding(t *testing.T) {
+	const cursor = "a+b/c==&d e"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			fmt.Fprintf(w, `{"items":[1],"next":%q}`, cursor)
+		case cursor:
+			if r.URL.Query().Get("q") != "x y" || len(r.URL.Query()) != 2 {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"items":[2],"next":""}`)
+		default:
+			t.Errorf("cursor was not encoded: %s", r.URL.RawQuery)
+			fmt.Fprint(w, `{"items":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL, HttpClient.Data{"q": "x y"}, HttpClient.Cursor("cursor", "next")).Items("items")
+	if got := collect(t, p); fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestPaginator_LinkHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page*3 < len(pageItems) {
+			w.Header().Add("Link", fmt.Sprintf(`</items?page=%d>; rel="next", </items?page=3>; rel="last"`, page+1))
+		}
+		HttpClient.Json().NewEncoder(w).Encode(pageSlice((page-1)*3, 3))
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL+"/items", nil, HttpClient.LinkHeader())
+	got := collect(t, p)
+	if fmt.Sprint(got) != fmt.Sprint(pageItems) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+// Link 头中的地址原样请求，未编码的 base64 游标不能被截断
+func TestPaginator_LinkHeaderVerbatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RawQuery {
+		case "":
+			w.Header().Set("Link", `</items?cursor=YWJj==&flag>; rel="next"`)
+			fmt.Fprint(w, `[1]`)
+		case "cursor=YWJj==&flag":
+			fmt.Fprint(w, `[2]`)
+		default:
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL+"/items", nil, HttpClient.LinkHeader())
+	if got := collect(t, p); fmt.Sprint(got) != "[1 2]" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+type stringPage struct {
+	HttpClient.PageStrategy
+}
+
+func (s stringPage) First(reqUrl string, data HttpClient.Data) (string, HttpClient.Data) {
+	return reqUrl, HttpClient.Data{"page": "1"}
+}
+
+func TestPaginator_InvalidPageParam(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[1]`)
+	}))
+	defer server.Close()
+
+	p := HttpClient.NewRequest().Paginate(server.URL, nil, stringPage{HttpClient.PageNumber("page", 1)})
+	for p.Next() {
+	}
+	if p.Err() == nil || p.Pages() != 1 {
+		t.Fatalf("expected an error for a non-int page, got %v after %d pages", p.Err(), p.Pages())
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := HttpClient.ParseLinkHeader([]string{
+		`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=9>; rel="last"`,
+		`<https://api.example.com/items?page=1>; rel="first prev"`,
+	})
+
+	if links["next"] != "https://api.example.com/items?page=2" || links["last"] != "https://api.example.com/items?page=9" {
+		t.Fatalf("unexpected links %v", links)
+	}
+	if links["first"] != links["prev"] {
+		t.Fatalf("unexpected links %v", links)
+	}
+
+	//地址和参数中的逗号不是分隔符
+	links = HttpClient.ParseLinkHeader([]string{
+		`<https://api.example.com/items?ids=1,2,3&page=2>; rel="next"; title="a, b", <https://api.example.com/items?ids=1,2,3&page=1>; rel="prev"`,
+	})
+	if links["next"] != "https://api.example.com/items?ids=1,2,3&page=2" || links["prev"] != "https://api.example.com/items?ids=1,2,3&page=1" {
+		t.Fatalf("unexpected links %v", links)
+	}
+}
@@ -0,0 +1,347 @@
+package HttpClient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNoHealthyProxy = errors.New("no healthy proxy available")
+
+// 经过代理的请求失败时返回，Proxy 为本次使用的代理，可以传给 ProxyPool.MarkFailed
+type ProxyError struct {
+	Proxy *url.URL
+	Err   error
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("goutils.HttpClient: proxy %s: %v", e.Proxy.Redacted(), e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+type selectedProxyKey struct{}
+
+// 记录 Transport 为请求选择的代理，重定向时为最后一次使用的代理
+type selectedProxy struct {
+	mu  sync.Mutex
+	url *url.URL
+}
+
+func (s *selectedProxy) set(u *url.URL) {
+	s.mu.Lock()
+	s.url = u
+	s.mu.Unlock()
+}
+
+func (s *selectedProxy) get() *url.URL {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.url
+}
+
+// 代理认证，同时适用于 http 代理和 socks5 代理
+func (r *Request) ProxyAuth(username, password string) *Request {
+	r.proxyUsername = username
+	r.proxyPassword = password
+	return r
+}
+
+// 按请求选择代理，优先于 Proxy，返回 nil 表示直连
+func (r *Request) SetProxyFunc(f func(req *http.Request) (*url.URL, error)) *Request {
+	r.proxyFunc = f
+	return r
+}
+
+// 不走代理的主机列表，格式同 NO_PROXY：
+// "*"、"example.com"（含子域名）、".example.com" 或 "*.example.com"（仅子域名）、
+// "10.0.0.0/8"、"127.0.0.1"、"example.com:8080"
+func (r *Request) NoProxy(hosts ...string) *Request {
+	r.noProxy = append(r.noProxy, hosts...)
+	return r
+}
+
+func (r *Request) buildProxy() (func(*http.Request) (*url.URL, error), error) {
+	proxy := r.proxyFunc
+	if proxy == nil && r.proxy != "" {
+		purl, err := parseProxyUrl(r.proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = http.ProxyURL(purl)
+	}
+
+	if proxy == nil {
+		if len(r.noProxy) == 0 {
+			return nil, nil
+		}
+		proxy = http.ProxyFromEnvironment
+	}
+
+	username, password := r.proxyUsername, r.proxyPassword
+	noProxy := r.noProxy
+
+	return func(req *http.Request) (*url.URL, error) {
+		if matchNoProxy(req.URL.Host, req.URL.Scheme, noProxy) {
+			return nil, nil
+		}
+
+		u, err := proxy(req)
+		if err != nil || u == nil {
+			return u, err
+		}
+		if selected, ok := req.Context().Value(selectedProxyKey{}).(*selectedProxy); ok {
+			selected.set(u)
+		}
+
+		if username != "" && u.User == nil {
+			cp := *u
+			cp.User = url.UserPassword(username, password)
+			u = &cp
+		}
+
+		return u, nil
+	}, nil
+}
+
+func parseProxyUrl(proxy string) (*url.URL, error) {
+	purl, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	switch purl.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return purl, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", purl.Scheme)
+	}
+}
+
+func matchNoProxy(host string, scheme string, rules []string) bool {
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+		port = ""
+	}
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "ws": "80", "wss": "443"}[scheme]
+	}
+	hostname = strings.ToLower(strings.Trim(hostname, "[]"))
+	ip := net.ParseIP(hostname)
+
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "" {
+			continue
+		}
+		if rule == "*" {
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(rule); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if h, p, err := net.SplitHostPort(rule); err == nil {
+			if p != port {
+				continue
+			}
+			rule = h
+		}
+		rule = strings.Trim(rule, "[]")
+
+		if ruleIP := net.ParseIP(rule); ruleIP != nil {
+			if ip != nil && ruleIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(rule, "*.") {
+			rule = rule[1:]
+		}
+		if strings.HasPrefix(rule, ".") {
+			if strings.HasSuffix(hostname, rule) {
+				return true
+			}
+			continue
+		}
+		if hostname == rule || strings.HasSuffix(hostname, "."+rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type poolProxy struct {
+	url     *url.URL
+	healthy bool
+}
+
+// 轮询使用的代理池，失败的代理会被摘除，健康检查通过后重新启用
+type ProxyPool struct {
+	mu       sync.Mutex
+	proxies  []*poolProxy
+	next     int
+	username string
+	password string
+}
+
+func NewProxyPool(proxies ...string) (*ProxyPool, error) {
+	pool := &ProxyPool{}
+	for _, proxy := range proxies {
+		purl, err := parseProxyUrl(proxy)
+		if err != nil {
+			return nil, err
+		}
+		pool.proxies = append(pool.proxies, &poolProxy{url: purl, healthy: true})
+	}
+
+	if len(pool.proxies) == 0 {
+		return nil, errors.New("proxy pool is empty")
+	}
+
+	return pool, nil
+}
+
+// 代理地址中没有认证信息时使用，请求和健康检查都会带上
+func (p *ProxyPool) ProxyAuth(username, password string) *ProxyPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.username = username
+	p.password = password
+	return p
+}
+
+// 可直接传给 SetProxyFunc，选中的代理可从 Response.Proxy 或 ProxyError 中取得
+func (p *ProxyPool) Proxy(req *http.Request) (*url.URL, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := 0; i < len(p.proxies); i++ {
+		proxy := p.proxies[(p.next+i)%len(p.proxies)]
+		if proxy.healthy {
+			p.next = (p.next + i + 1) % len(p.proxies)
+			return p.withAuth(proxy.url), nil
+		}
+	}
+
+	return nil, ErrNoHealthyProxy
+}
+
+func (p *ProxyPool) withAuth(u *url.URL) *url.URL {
+	if p.username == "" || u.User != nil {
+		return u
+	}
+	cp := *u
+	cp.User = url.UserPassword(p.username, p.password)
+	return &cp
+}
+
+func (p *ProxyPool) MarkFailed(proxy *url.URL) {
+	p.mark(proxy, false)
+}
+
+func (p *ProxyPool) MarkHealthy(proxy *url.URL) {
+	p.mark(proxy, true)
+}
+
+func (p *ProxyPool) mark(proxy *url.URL, healthy bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	//按地址比较，忽略 ProxyAuth 加上的认证信息
+	for _, pp := range p.proxies {
+		if pp.url.Scheme == proxy.Scheme && pp.url.Host == proxy.Host {
+			pp.healthy = healthy
+		}
+	}
+}
+
+func (p *ProxyPool) Healthy() []*url.URL {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	list := make([]*url.URL, 0)
+	for _, pp := range p.proxies {
+		if pp.healthy {
+			list = append(list, pp.url)
+		}
+	}
+	return list
+}
+
+// 立即检查一次所有代理：通过代理请求 checkUrl，返回 2xx/3xx 视为可用
+func (p *ProxyPool) Check(ctx context.Context, checkUrl string, timeout time.Duration) {
+	p.mu.Lock()
+	proxies := make([]*url.URL, 0, len(p.proxies))
+	for _, pp := range p.proxies {
+		proxies = append(proxies, p.withAuth(pp.url))
+	}
+	p.mu.Unlock()
+
+	wg := sync.WaitGroup{}
+	for _, proxy := range proxies {
+		wg.Add(1)
+		go func(proxy *url.URL) {
+			defer wg.Done()
+			p.mark(proxy, checkProxy(ctx, proxy, checkUrl, timeout))
+		}(proxy)
+	}
+	wg.Wait()
+}
+
+// 按 interval 周期性执行 Check，直到 ctx 取消
+func (p *ProxyPool) HealthCheck(ctx context.Context, checkUrl string, interval time.Duration, timeout time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Check(ctx, checkUrl, timeout)
+			}
+		}
+	}()
+}
+
+func checkProxy(ctx context.Context, proxy *url.URL, checkUrl string, timeout time.Duration) bool {
+	c := &http.Client{
+		Transport: &http.Transport{
+			Proxy:             http.ProxyURL(proxy),
+			DisableKeepAlives: true,
+		},
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, checkUrl, nil)
+	if err != nil {
+		return false
+	}
+
+	res, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+
+	return res.StatusCode >= 200 && res.StatusCode < 400
+}
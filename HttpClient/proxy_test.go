@@ -0,0 +1,294 @@
+package HttpClient_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+// 转发普通 http 请求的代理，要求 Proxy-Authorization
+func newForwardProxy(t *testing.T, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpwYXNz" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+
+		req, _ := http.NewRequest(r.Method, r.URL.String(), r.Body)
+		res, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer res.Body.Close()
+		w.Header().Set("X-Proxied", "1")
+		w.WriteHeader(res.StatusCode)
+		io.Copy(w, res.Body)
+	}))
+}
+
+// 仅支持用户名密码认证和 CONNECT 的最小 socks5 服务
+func newSocks5Proxy(t *testing.T, hits *int32) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				buf := make([]byte, 512)
+				//greeting
+				io.ReadFull(conn, buf[:2])
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{5, 2})
+				//username/password
+				io.ReadFull(conn, buf[:2])
+				user := make([]byte, buf[1])
+				io.ReadFull(conn, user)
+				io.ReadFull(conn, buf[:1])
+				pass := make([]byte, buf[0])
+				io.ReadFull(conn, pass)
+				if string(user) != "user" || string(pass) != "pass" {
+					conn.Write([]byte{1, 1})
+					return
+				}
+				conn.Write([]byte{1, 0})
+				//connect request
+				io.ReadFull(conn, buf[:4])
+				var host string
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					name := make([]byte, buf[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				}
+				io.ReadFull(conn, buf[:2])
+				port := binary.BigEndian.Uint16(buf[:2])
+
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer target.Close()
+				atomic.AddInt32(hits, 1)
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(target, conn)
+				io.Copy(conn, target)
+			}(conn)
+		}
+	}()
+
+	return ln
+}
+
+func newTarget() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestRequest_ProxyAuth(t *testing.T) {
+	var hits int32
+	proxy := newForwardProxy(t, &hits)
+	defer proxy.Close()
+	target := newTarget()
+	defer target.Close()
+
+	resp, err := HttpClient.NewRequest().Proxy(proxy.URL).ProxyAuth("user", "pass").GET(target.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != http.StatusOK || resp.Headers().Get("X-Proxied") != "1" {
+		t.Fatalf("request was not proxied: %d", resp.StatusCode())
+	}
+
+	resp, err = HttpClient.NewRequest().Proxy(proxy.URL).GET(target.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != http.StatusProxyAuthRequired {
+		t.Fatalf("expected 407, got %d", resp.StatusCode())
+	}
+}
+
+func TestRequest_ProxySocks5(t *testing.T) {
+	var hits int32
+	ln := newSocks5Proxy(t, &hits)
+	defer ln.Close()
+	target := newTarget()
+	defer target.Close()
+
+	resp, err := HttpClient.NewRequest().Proxy("socks5://"+ln.Addr().String()).ProxyAuth("user", "pass").GET(target.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "ok" || atomic.LoadInt32(&hits) != 1 {
+		t.Fatalf("request was not proxied: %q", s)
+	}
+}
+
+func TestRequest_NoProxy(t *testing.T) {
+	var hits int32
+	proxy := newForwardProxy(t, &hits)
+	defer proxy.Close()
+	target := newTarget()
+	defer target.Close()
+
+	resp, err := HttpClient.NewRequest().Proxy(proxy.URL).NoProxy("example.com", "127.0.0.0/8").GET(target.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != http.StatusOK || atomic.LoadInt32(&hits) != 0 {
+		t.Fatalf("request should bypass proxy")
+	}
+}
+
+func TestRequest_SetProxyFunc(t *testing.T) {
+	var hits int32
+	proxy := newForwardProxy(t, &hits)
+	defer proxy.Close()
+	target := newTarget()
+	defer target.Close()
+
+	purl, _ := url.Parse(proxy.URL)
+	req := HttpClient.NewRequest().ProxyAuth("user", "pass").SetProxyFunc(func(r *http.Request) (*url.URL, error) {
+		if r.URL.Query().Get("direct") == "1" {
+			return nil, nil
+		}
+		return purl, nil
+	})
+
+	if _, err := req.GET(target.URL, HttpClient.Data{"direct": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := req.GET(target.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Fatalf("expected one proxied request, got %d", atomic.LoadInt32(&hits))
+	}
+}
+
+func TestProxyPool(t *testing.T) {
+	var hits1, hits2 int32
+	proxy1 := newForwardProxy(t, &hits1)
+	defer proxy1.Close()
+	proxy2 := newForwardProxy(t, &hits2)
+	target := newTarget()
+	defer target.Close()
+
+	//健康检查需要认证信息，直接写在代理地址中
+	withAuth := func(s string) string {
+		u, _ := url.Parse(s)
+		u.User = url.UserPassword("user", "pass")
+		return u.String()
+	}
+	pool, err := HttpClient.NewProxyPool(withAuth(proxy1.URL), withAuth(proxy2.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := HttpClient.NewRequest().SetProxyFunc(pool.Proxy)
+	for i := 0; i < 4; i++ {
+		if _, err := req.GET(target.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if atomic.LoadInt32(&hits1) != 2 || atomic.LoadInt32(&hits2) != 2 {
+		t.Fatalf("expected round robin, got %d/%d", hits1, hits2)
+	}
+
+	//proxy2 下线后健康检查将其摘除
+	proxy2.Close()
+	pool.Check(context.Background(), target.URL, time.Second)
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0].String() != withAuth(proxy1.URL) {
+		t.Fatalf("unexpected healthy proxies %v", healthy)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := req.GET(target.URL, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	//2 次轮询 + 1 次健康检查 + 2 次请求
+	if n := atomic.LoadInt32(&hits1); n != 5 {
+		t.Fatalf("expected requests to use proxy1 only, got %d", n)
+	}
+
+	pool.MarkFailed(pool.Healthy()[0])
+	if _, err := req.GET(target.URL, nil); err == nil {
+		t.Fatal("expected error when no proxy is healthy")
+	}
+}
+
+func TestProxyPool_SelectedProxy(t *testing.T) {
+	var hits1, hits2 int32
+	proxy1 := newForwardProxy(t, &hits1)
+	defer proxy1.Close()
+	proxy2 := newForwardProxy(t, &hits2)
+	target := newTarget()
+	defer target.Close()
+
+	//认证信息只设置在代理池上，健康检查同样需要
+	pool, err := HttpClient.NewProxyPool(proxy1.URL, proxy2.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.ProxyAuth("user", "pass")
+	pool.Check(context.Background(), target.URL, time.Second)
+	if len(pool.Healthy()) != 2 {
+		t.Fatalf("health check did not send proxy credentials: %v", pool.Healthy())
+	}
+
+	req := HttpClient.NewRequest().SetProxyFunc(pool.Proxy)
+	resp, err := req.GET(target.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Proxy() == nil || resp.Proxy().Host != strings.TrimPrefix(proxy1.URL, "http://") {
+		t.Fatalf("unexpected proxy %v", resp.Proxy())
+	}
+
+	//proxy2 下线，请求失败时从错误中取得代理并摘除
+	proxy2.Close()
+	_, err = req.GET(target.URL, nil)
+	var proxyErr *HttpClient.ProxyError
+	if !errors.As(err, &proxyErr) || proxyErr.Proxy.Host != strings.TrimPrefix(proxy2.URL, "http://") {
+		t.Fatalf("expected ProxyError, got %v", err)
+	}
+	if strings.Contains(err.Error(), "pass") {
+		t.Fatalf("password leaked in error: %v", err)
+	}
+	pool.MarkFailed(proxyErr.Proxy)
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0].String() != proxy1.URL {
+		t.Fatalf("unexpected healthy proxies %v", healthy)
+	}
+
+	if resp, err := HttpClient.NewRequest().GET(target.URL, nil); err != nil || resp.Proxy() != nil {
+		t.Fatalf("unexpected proxy %v %v", resp.Proxy(), err)
+	}
+}
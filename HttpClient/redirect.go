@@ -0,0 +1,124 @@
+package HttpClient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var ErrCrossHostRedirect = errors.New("goutils.HttpClient: redirect to another host is not allowed")
+
+// 重定向策略，签名与 http.Client.CheckRedirect 相同
+type RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+// 标准库默认最多跟随 10 次重定向
+const defaultMaxRedirects = 10
+
+// 依次执行多个重定向策略，任一策略返回错误即停止跳转，
+// 跳转次数由 SetMaxRedirects 限制，未设置时和标准库一样最多跟随 10 次
+func (r *Request) SetRedirectPolicy(policies ...RedirectPolicy) *Request {
+	r.redirectPolicies = policies
+	r.checkRedirect = nil
+	r.resetClient()
+	return r
+}
+
+// 最多跟随 n 次重定向，n 小于等于 0 时恢复默认的 10 次
+func (r *Request) SetMaxRedirects(n int) *Request {
+	r.maxRedirects = n
+	r.resetClient()
+	return r
+}
+
+// SetCheckRedirect 设置的函数优先，否则由次数限制和重定向策略组合而成
+func (r *Request) getCheckRedirect() func(req *http.Request, via []*http.Request) error {
+	if r.checkRedirect != nil {
+		return r.checkRedirect
+	}
+	if r.maxRedirects <= 0 && len(r.redirectPolicies) == 0 {
+		return nil
+	}
+
+	maxRedirects := r.maxRedirects
+	policies := append([]RedirectPolicy(nil), r.redirectPolicies...)
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects <= 0 {
+			//和 http.Client 默认的 CheckRedirect 一致
+			if len(via) >= defaultMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", defaultMaxRedirects)
+			}
+		} else if len(via) > maxRedirects {
+			return fmt.Errorf("goutils.HttpClient: stopped after %d redirects", maxRedirects)
+		}
+		for _, policy := range policies {
+			if err := policy(req, via); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// 不跟随重定向，直接返回 3xx 响应
+func NoRedirects() RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+}
+
+// 只允许跳转到与首个请求相同的 host（含端口）
+func SameHost() RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > 0 && !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			return ErrCrossHostRedirect
+		}
+		return nil
+	}
+}
+
+// 跳转时保留首个请求中的指定请求头，
+// 标准库在跳转到其他域名时会丢弃 Authorization、Cookie 等敏感头，需显式列出才会带上
+func PreserveHeaders(headers ...string) RedirectPolicy {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) == 0 {
+			return nil
+		}
+		for _, header := range headers {
+			header = http.CanonicalHeaderKey(header)
+			if values, ok := via[0].Header[header]; ok {
+				req.Header[header] = append([]string(nil), values...)
+			}
+		}
+		return nil
+	}
+}
+
+// 按发生顺序返回途经的重定向响应，其 Request.URL 为被重定向的地址，响应体已关闭
+func (r *Response) Redirects() []*http.Response {
+	if r == nil || r.Resp == nil || r.Resp.Request == nil {
+		return nil
+	}
+
+	chain := make([]*http.Response, 0)
+	for res := r.Resp.Request.Response; res != nil; {
+		chain = append(chain, res)
+		if res.Request == nil {
+			break
+		}
+		res = res.Request.Response
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// 重定向后最终请求的地址
+func (r *Response) FinalUrl() string {
+	if r == nil || r.Resp == nil || r.Resp.Request == nil {
+		return r.Url()
+	}
+	return r.Resp.Request.URL.String()
+}
@@ -0,0 +1,111 @@
+package HttpClient_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func TestRequest_SetRedirectPolicy(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/b", http.StatusFound) })
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/c", http.StatusMovedPermanently) })
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("c")) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := HttpClient.NewRequest().SetMaxRedirects(2).GET(server.URL+"/a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "c" || resp.FinalUrl() != server.URL+"/c" {
+		t.Fatalf("unexpected final response %q %s", s, resp.FinalUrl())
+	}
+	chain := resp.Redirects()
+	if len(chain) != 2 || chain[0].StatusCode != http.StatusFound || chain[0].Request.URL.Path != "/a" ||
+		chain[1].StatusCode != http.StatusMovedPermanently || chain[1].Request.URL.Path != "/b" {
+		t.Fatalf("unexpected redirect chain %v", chain)
+	}
+
+	if _, err := HttpClient.NewRequest().SetMaxRedirects(1).GET(server.URL+"/a", nil); err == nil ||
+		!strings.Contains(err.Error(), "stopped after 1 redirects") {
+		t.Fatalf("expected max redirects error, got %v", err)
+	}
+
+	resp, err = HttpClient.NewRequest().SetRedirectPolicy(HttpClient.NoRedirects()).GET(server.URL+"/a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != http.StatusFound || resp.Headers().Get("Location") != "/b" || len(resp.Redirects()) != 0 {
+		t.Fatalf("expected the 302 response, got %d", resp.StatusCode())
+	}
+}
+
+func TestRedirect_SameHostAndPreserveHeaders(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key")))
+	}))
+	defer target.Close()
+	//以 localhost 访问，与 127.0.0.1 视为不同的 host
+	other := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other+"/", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	newRequest := func() *HttpClient.Request {
+		return HttpClient.NewRequest().
+			SetBasicAuth("user", "pass").
+			AddHeaders(map[string]string{"X-Api-Key": "k"})
+	}
+
+	_, err := newRequest().SetRedirectPolicy(HttpClient.SameHost()).GET(origin.URL, nil)
+	if !errors.Is(err, HttpClient.ErrCrossHostRedirect) {
+		t.Fatalf("expected cross host error, got %v", err)
+	}
+
+	resp, err := newRequest().GET(origin.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "|k" {
+		t.Fatalf("Authorization should be dropped by default, got %q", s)
+	}
+
+	resp, err = newRequest().SetMaxRedirects(5).SetRedirectPolicy(HttpClient.PreserveHeaders("authorization")).GET(origin.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "Basic dXNlcjpwYXNz|k" {
+		t.Fatalf("Authorization was not preserved, got %q", s)
+	}
+}
+
+// 没有 SetMaxRedirects 时同样受默认的 10 次限制，不会在同域名的循环跳转中卡死
+func TestRedirect_DefaultLimit(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer server.Close()
+
+	for _, policy := range []HttpClient.RedirectPolicy{HttpClient.SameHost(), HttpClient.PreserveHeaders("X-Api-Key")} {
+		hits = 0
+		_, err := HttpClient.NewRequest().SetRedirectPolicy(policy).GET(server.URL, nil)
+		if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") || hits != 10 {
+			t.Fatalf("expected default redirect limit, got %v after %d requests", err, hits)
+		}
+	}
+
+	hits = 0
+	_, err := HttpClient.NewRequest().SetRedirectPolicy(HttpClient.SameHost()).SetMaxRedirects(15).GET(server.URL, nil)
+	if err == nil || !strings.Contains(err.Error(), "stopped after 15 redirects") || hits != 16 {
+		t.Fatalf("expected SetMaxRedirects to replace the default limit, got %v after %d requests", err, hits)
+	}
+}
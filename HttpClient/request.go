@@ -2,6 +2,7 @@ package HttpClient
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -11,32 +12,46 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 type Data map[string]interface{}
 type File map[string]string
 
-var client = &http.Client{}
-
 type Request struct {
+	clientMu          sync.Mutex
 	client            *http.Client
 	transport         *http.Transport
 	debug             bool
 	url               string
+	rawUrl            string
 	method            string
 	time              int64
 	timeout           time.Duration
 	proxy             string
+	proxyUsername     string
+	proxyPassword     string
+	proxyFunc         func(req *http.Request) (*url.URL, error)
+	noProxy           []string
 	username          string
 	password          string
 	data              interface{}
+	rawBody           []byte
+	files             File
 	disableKeepAlives bool
 	tlsClientConfig   *tls.Config
 	jar               http.CookieJar
 	headers           map[string]string
 	cookies           map[string]string
 	checkRedirect     func(req *http.Request, via []*http.Request) error
+	redirectPolicies  []RedirectPolicy
+	maxRedirects      int
+	compression       bool
+	compressEncoding  string
+	compressMinSize   int
+	middlewares       []Middleware
 }
 
 func NewRequest() *Request {
@@ -64,6 +79,7 @@ func (r *Request) DisableKeepAlives(b bool) *Request {
 
 func (r *Request) SetCheckRedirect(f func(req *http.Request, via []*http.Request) error) *Request {
 	r.checkRedirect = f
+	r.resetClient()
 	return r
 }
 
@@ -135,10 +151,15 @@ func (r *Request) AddCookies(cookies map[string]string) *Request {
 
 func (r *Request) initCookies(req *http.Request) *Request {
 	if r.cookies != nil {
-		for k, v := range r.cookies {
+		keys := make([]string, 0, len(r.cookies))
+		for k := range r.cookies {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
 			req.AddCookie(&http.Cookie{
 				Name:  k,
-				Value: v,
+				Value: r.cookies[k],
 			})
 		}
 	}
@@ -177,12 +198,12 @@ func (r *Request) getTransport() (http.RoundTripper, error) {
 		}
 	}
 
-	if r.proxy != "" {
-		purl, err := url.Parse(r.proxy)
-		if err != nil {
-			return nil, err
-		}
-		r.transport.Proxy = http.ProxyURL(purl)
+	proxy, err := r.buildProxy()
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		r.transport.Proxy = proxy
 	}
 
 	r.transport.DisableKeepAlives = r.disableKeepAlives
@@ -191,29 +212,38 @@ func (r *Request) getTransport() (http.RoundTripper, error) {
 		r.transport.TLSClientConfig = r.tlsClientConfig
 	}
 
-	return http.RoundTripper(r.transport), nil
+	var rt http.RoundTripper = r.transport
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		rt = r.middlewares[i](rt)
+	}
+
+	return rt, nil
 }
 
-func (r *Request) buildClient() (*Request, error) {
+func (r *Request) buildClient() (*http.Client, error) {
+	r.clientMu.Lock()
+	defer r.clientMu.Unlock()
 	if r.client == nil {
 		t, err := r.getTransport()
 		if err != nil {
-			return r, err
+			return nil, err
 		}
-		//r.client
-		//client = &http.Client{
-		//	Transport:     t,
-		//	CheckRedirect: r.checkRedirect,
-		//	Jar:           r.jar,
-		//	Timeout:       time.Second * r.timeout,
-		//}
-		client.Transport = t
-		client.CheckRedirect = r.checkRedirect
-		client.Jar = r.jar
-		client.Timeout = time.Second * r.timeout
-		r.client = client
-	}
-	return r, nil
+		//每个 Request 使用独立的 client，避免代理等配置相互覆盖
+		r.client = &http.Client{
+			Transport:     t,
+			CheckRedirect: r.getCheckRedirect(),
+			Jar:           r.jar,
+			Timeout:       time.Second * r.timeout,
+		}
+	}
+	return r.client, nil
+}
+
+// 配置变更后丢弃已创建的 client，下次请求时重建
+func (r *Request) resetClient() {
+	r.clientMu.Lock()
+	r.client = nil
+	r.clientMu.Unlock()
 }
 
 func (r *Request) elapsedTime(t int64, resp *Response) *Request {
@@ -223,10 +253,14 @@ func (r *Request) elapsedTime(t int64, resp *Response) *Request {
 }
 
 func (r *Request) log() {
+	r.logRequest(r.method, r.url, r.headers, r.data)
+}
+
+func (r *Request) logRequest(method string, reqUrl string, headers map[string]string, body interface{}) {
 	if r.debug {
 		fmt.Printf("[goutils.HttpClient.Request]\n")
 		fmt.Printf("-------------------------------------------------------------------\n")
-		fmt.Printf("Request: %s %s\nHeaders: %v\nCookies: %v\nTimeout: %ds\nReqBody: %v\n", r.method, r.url, r.headers, r.cookies, r.timeout, r.data)
+		fmt.Printf("Request: %s %s\nHeaders: %v\nCookies: %v\nTimeout: %ds\nReqBody: %v\n", method, reqUrl, headers, r.cookies, r.timeout, body)
 		fmt.Printf("-------------------------------------------------------------------\n\n")
 	}
 }
@@ -257,7 +291,8 @@ func (r *Request) buildUrl(reqUrl string, data Data) (string, error) {
 	}
 
 	s := ""
-	for k, v := range data {
+	for _, k := range sortedKeys(data) {
+		v := data[k]
 		if val, ok := v.(string); ok {
 			s = val
 		} else {
@@ -284,6 +319,10 @@ func (r *Request) buildBody(data Data) (io.Reader, error) {
 		return nil, nil
 	}
 
+	if r.rawBody != nil {
+		return bytes.NewReader(r.rawBody), nil
+	}
+
 	if data == nil {
 		return strings.NewReader(""), nil
 	}
@@ -298,7 +337,8 @@ func (r *Request) buildBody(data Data) (io.Reader, error) {
 
 	body := make([]string, 0)
 	s := ""
-	for k, v := range data {
+	for _, k := range sortedKeys(data) {
+		v := data[k]
 		if val, ok := v.(string); ok {
 			s = val
 		} else {
@@ -320,18 +360,73 @@ func (r *Request) request(method string, reqUrl string, data Data) (*Response, e
 		return nil, errors.New("method and url is required")
 	}
 
+	defer r.log()
+
+	req, err := r.newHTTPRequest(method, reqUrl, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.send(req, reqUrl)
+}
+
+// 使用指定的请求头和请求体发送，不读写 Request 上记录的请求信息，可以并发调用
+func (r *Request) requestBody(method string, reqUrl string, headers map[string]string, body []byte) (*Response, error) {
+	if method == "" || reqUrl == "" {
+		return nil, errors.New("method and url is required")
+	}
+	defer r.logRequest(method, reqUrl, headers, string(body))
+
+	req, err := http.NewRequest(strings.ToUpper(method), reqUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	r.initCookies(req)
+	r.initBasicAuth(req)
+
+	return r.send(req, reqUrl)
+}
+
+func (r *Request) send(req *http.Request, reqUrl string) (*Response, error) {
 	resp := &Response{}
 	start := time.Now().UnixNano() / 1e6
 	defer r.elapsedTime(start, resp)
-	defer r.log()
 
-	r.data = data
-	r.url = reqUrl
-	_, err := r.buildClient()
+	client, err := r.buildClient()
+	if err != nil {
+		return nil, err
+	}
+
+	decode, err := r.initCompression(req)
 	if err != nil {
 		return nil, err
 	}
 
+	selected := &selectedProxy{}
+	req = req.WithContext(context.WithValue(req.Context(), selectedProxyKey{}, selected))
+	res, err := client.Do(req)
+	if err != nil {
+		if proxy := selected.get(); proxy != nil {
+			return nil, &ProxyError{Proxy: proxy, Err: err}
+		}
+		return nil, err
+	}
+
+	resp.url = reqUrl
+	resp.proxy = selected.get()
+	resp.Resp = res
+	resp.decodeBody(decode)
+	return resp, nil
+}
+
+func (r *Request) newHTTPRequest(method string, reqUrl string, data Data) (*http.Request, error) {
+	r.data = data
+	r.rawUrl = reqUrl
+	r.url = reqUrl
+	r.files = nil
 	r.method = strings.ToUpper(method)
 	if r.method == "GET" || r.method == "DELETE" {
 		reqUrl, err := r.buildUrl(reqUrl, data)
@@ -348,7 +443,6 @@ func (r *Request) request(method string, reqUrl string, data Data) (*Response, e
 	}
 
 	req, err := http.NewRequest(r.method, r.url, body)
-
 	if err != nil {
 		return nil, err
 	}
@@ -357,15 +451,7 @@ func (r *Request) request(method string, reqUrl string, data Data) (*Response, e
 	r.initCookies(req)
 	r.initBasicAuth(req)
 
-	res, err := r.client.Do(req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	resp.url = reqUrl
-	resp.Resp = res
-	return resp, nil
+	return req, nil
 }
 
 func (r *Request) sendFile(reqUrl string, files File, data Data) (*Response, error) {
@@ -375,7 +461,8 @@ func (r *Request) sendFile(reqUrl string, files File, data Data) (*Response, err
 
 	bodyBuffer := &bytes.Buffer{}
 	bodyWrite := multipart.NewWriter(bodyBuffer)
-	for fieldname, filename := range files {
+	for _, fieldname := range sortedFileKeys(files) {
+		filename := files[fieldname]
 		fileWrite, err := bodyWrite.CreateFormFile(fieldname, filename)
 		if err != nil {
 			return nil, err
@@ -397,7 +484,8 @@ func (r *Request) sendFile(reqUrl string, files File, data Data) (*Response, err
 	}
 
 	if data != nil {
-		for key, value := range data {
+		for _, key := range sortedKeys(data) {
+			value := data[key]
 			if v, ok := value.(string); ok {
 				err := bodyWrite.WriteField(key, v)
 				if err != nil {
@@ -423,18 +511,12 @@ func (r *Request) sendFile(reqUrl string, files File, data Data) (*Response, err
 		return nil, err
 	}
 
-	resp := &Response{}
-	start := time.Now().UnixNano() / 1e6
-	defer r.elapsedTime(start, resp)
 	defer r.log()
 
 	r.url = reqUrl
+	r.rawUrl = reqUrl
 	r.data = data
-	_, err = r.buildClient()
-	if err != nil {
-		return nil, err
-	}
-
+	r.files = files
 	r.method = "POST"
 
 	req, err := http.NewRequest(r.method, r.url, bodyBuffer)
@@ -447,14 +529,7 @@ func (r *Request) sendFile(reqUrl string, files File, data Data) (*Response, err
 	r.initBasicAuth(req)
 	req.Header.Set("Content-Type", contentType)
 
-	res, err := r.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	resp.url = reqUrl
-	resp.Resp = res
-	return resp, nil
+	return r.send(req, reqUrl)
 }
 
 func (r *Request) GET(reqUrl string, data Data) (*Response, error) {
@@ -5,13 +5,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 )
 
 type Response struct {
-	time int64
-	url  string
-	Resp *http.Response
-	body []byte
+	time     int64
+	url      string
+	Resp     *http.Response
+	body     []byte
+	wire     *countingReader
+	encoding []string
+	proxy    *url.URL
 }
 
 func (r *Response) Time() string {
@@ -28,6 +32,14 @@ func (r *Response) Url() string {
 	return ""
 }
 
+// 本次请求使用的代理，直连时为 nil
+func (r *Response) Proxy() *url.URL {
+	if r != nil {
+		return r.proxy
+	}
+	return nil
+}
+
 func (r *Response) StatusCode() int {
 	if r == nil || r.Resp == nil {
 		return 0
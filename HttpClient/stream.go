@@ -0,0 +1,301 @@
+package HttpClient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 不缓冲响应体的请求，适用于长连接的流式接口，调用方负责关闭 Response
+func (r *Request) Stream(ctx context.Context, method string, reqUrl string, data Data) (*Response, error) {
+	return r.stream(ctx, method, reqUrl, data, nil)
+}
+
+func (r *Request) stream(ctx context.Context, method string, reqUrl string, data Data, header http.Header) (*Response, error) {
+	if method == "" || reqUrl == "" {
+		return nil, errors.New("method and url is required")
+	}
+
+	r.clientMu.Lock()
+	t, err := r.getTransport()
+	if err != nil {
+		r.clientMu.Unlock()
+		return nil, err
+	}
+
+	//流式响应不能使用整体超时，由 ctx 控制生命周期
+	c := &http.Client{
+		Transport:     t,
+		CheckRedirect: r.getCheckRedirect(),
+		Jar:           r.jar,
+	}
+	r.clientMu.Unlock()
+
+	defer r.log()
+
+	req, err := r.newHTTPRequest(method, reqUrl, data)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	decode, err := r.initCompression(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{url: r.url, Resp: res}
+	resp.decodeBody(decode)
+	return resp, nil
+}
+
+func (r *Response) Events() *EventReader {
+	return NewEventReader(r.Resp.Body)
+}
+
+func (r *Response) NDJSON() *NDJSONDecoder {
+	return NewNDJSONDecoder(r.Resp.Body)
+}
+
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// text/event-stream 解析器
+type EventReader struct {
+	reader *bufio.Reader
+	lastID string
+	retry  time.Duration
+}
+
+func NewEventReader(r io.Reader) *EventReader {
+	return &EventReader{reader: bufio.NewReader(r)}
+}
+
+// 读取下一个事件，流结束时返回 io.EOF，未以空行结束的事件会被丢弃
+func (er *EventReader) Read() (*Event, error) {
+	ev := &Event{}
+	var data strings.Builder
+	hasData := false
+
+	for {
+		line, err := er.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			if !hasData {
+				ev = &Event{Retry: ev.Retry}
+				continue
+			}
+			ev.ID = er.lastID
+			ev.Data = strings.TrimSuffix(data.String(), "\n")
+			if ev.Event == "" {
+				ev.Event = "message"
+			}
+			return ev, nil
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				er.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				ev.Retry = time.Duration(ms) * time.Millisecond
+				er.retry = ev.Retry
+			}
+		}
+	}
+}
+
+func (er *EventReader) LastEventID() string {
+	return er.lastID
+}
+
+// 最近一次收到的 retry 字段，没有数据的事件中的 retry 同样生效
+func (er *EventReader) Retry() time.Duration {
+	return er.retry
+}
+
+// SSE 客户端，断线后携带 Last-Event-ID 自动重连
+type EventSource struct {
+	req         *Request
+	url         string
+	data        Data
+	lastEventID string
+	retry       time.Duration
+	maxRetries  int
+}
+
+func (r *Request) EventSource(reqUrl string, data Data) *EventSource {
+	return &EventSource{
+		req:   r,
+		url:   reqUrl,
+		data:  data,
+		retry: 3 * time.Second,
+	}
+}
+
+func (es *EventSource) Retry(d time.Duration) *EventSource {
+	es.retry = d
+	return es
+}
+
+// 连续重连失败的最大次数，0 表示不限制
+func (es *EventSource) MaxRetries(n int) *EventSource {
+	es.maxRetries = n
+	return es
+}
+
+func (es *EventSource) SetLastEventID(id string) *EventSource {
+	es.lastEventID = id
+	return es
+}
+
+func (es *EventSource) LastEventID() string {
+	return es.lastEventID
+}
+
+// 持续接收事件直到 ctx 取消、handler 返回错误、服务端返回 204 或超过重连次数
+func (es *EventSource) Subscribe(ctx context.Context, handler func(*Event) error) error {
+	failures := 0
+	for {
+		received, err := es.connect(ctx, handler)
+		if err == errStreamDone {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if e, ok := err.(*fatalError); ok {
+			return e.err
+		}
+
+		if received {
+			failures = 0
+		}
+		failures++
+		if es.maxRetries > 0 && failures > es.maxRetries {
+			return fmt.Errorf("eventsource: giving up after %d retries: %v", es.maxRetries, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(es.retry):
+		}
+	}
+}
+
+var errStreamDone = errors.New("eventsource: stream closed by server")
+
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string {
+	return e.err.Error()
+}
+
+func (es *EventSource) connect(ctx context.Context, handler func(*Event) error) (bool, error) {
+	header := http.Header{}
+	header.Set("Accept", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	if es.lastEventID != "" {
+		header.Set("Last-Event-ID", es.lastEventID)
+	}
+
+	resp, err := es.req.stream(ctx, http.MethodGet, es.url, es.data, header)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Close()
+
+	if resp.StatusCode() == http.StatusNoContent {
+		return false, errStreamDone
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return false, &fatalError{fmt.Errorf("eventsource: unexpected status code %d", resp.StatusCode())}
+	}
+	if ct := resp.Headers().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		return false, &fatalError{fmt.Errorf("eventsource: unexpected content type %q", ct)}
+	}
+
+	received := false
+	reader := resp.Events()
+	reader.lastID = es.lastEventID
+	for {
+		ev, err := reader.Read()
+		if reader.Retry() > 0 {
+			es.retry = reader.Retry()
+		}
+		if err != nil {
+			return received, err
+		}
+
+		received = true
+		es.lastEventID = ev.ID
+
+		if err := handler(ev); err != nil {
+			return received, &fatalError{err}
+		}
+	}
+}
+
+// 按行解码 NDJSON（application/x-ndjson）流
+type NDJSONDecoder struct {
+	reader *bufio.Reader
+}
+
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	return &NDJSONDecoder{reader: bufio.NewReader(r)}
+}
+
+// 解码下一行到 v，跳过空行，流结束时返回 io.EOF
+func (d *NDJSONDecoder) Decode(v interface{}) error {
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			return json.Unmarshal(line, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
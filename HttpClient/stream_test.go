@@ -0,0 +1,162 @@
+package HttpClient_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func TestEventReader_Read(t *testing.T) {
+	stream := ": comment\n" +
+		"retry: 1500\n\n" +
+		"id: 1\nevent: greeting\ndata: hello\ndata: world\n\n" +
+		"data:no space\r\n\r\n" +
+		"id: 3\ndata: incomplete"
+
+	reader := HttpClient.NewEventReader(strings.NewReader(stream))
+
+	ev, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.ID != "1" || ev.Event != "greeting" || ev.Data != "hello\nworld" || ev.Retry != 1500*time.Millisecond {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	ev, err = reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.ID != "1" || ev.Event != "message" || ev.Data != "no space" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	if _, err = reader.Read(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	if reader.Retry() != 1500*time.Millisecond {
+		t.Fatalf("unexpected retry %v", reader.Retry())
+	}
+}
+
+func TestEventSource_Subscribe(t *testing.T) {
+	var conns int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&conns, 1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "retry: 10\nid: 1\ndata: a\n\nid: 2\ndata: b\n\n")
+		case 2:
+			if r.Header.Get("Last-Event-ID") != "2" {
+				http.Error(w, "missing Last-Event-ID", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "id: 3\ndata: c\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got := make([]string, 0)
+	es := HttpClient.NewRequest().SetTimeout(1).EventSource(server.URL, nil)
+	err := es.Subscribe(ctx, func(ev *HttpClient.Event) error {
+		got = append(got, ev.Data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(got, ",") != "a,b,c" || es.LastEventID() != "3" {
+		t.Fatalf("got %v, last id %s", got, es.LastEventID())
+	}
+}
+
+// 流结束前只带 retry 的事件也要用于下一次重连
+func TestEventSource_RetryWithoutData(t *testing.T) {
+	var conns int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&conns, 1) > 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: a\n\nretry: 10\n\n")
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	err := HttpClient.NewRequest().EventSource(server.URL, nil).Subscribe(ctx, func(ev *HttpClient.Event) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected reconnect after 10ms, got %v", err)
+	}
+}
+
+func TestEventSource_HandlerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: a\n\n")
+	}))
+	defer server.Close()
+
+	stop := errors.New("stop")
+	err := HttpClient.NewRequest().EventSource(server.URL, nil).Subscribe(context.Background(), func(ev *HttpClient.Event) error {
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+}
+
+func TestResponse_NDJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "{\"n\":%d}\n\n", i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	resp, err := HttpClient.NewRequest().Stream(context.Background(), http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Close()
+
+	sum := 0
+	dec := resp.NDJSON()
+	for {
+		var v struct{ N int }
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum += v.N
+	}
+
+	if sum != 6 {
+		t.Fatalf("expected 6, got %d", sum)
+	}
+}
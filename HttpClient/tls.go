@@ -0,0 +1,179 @@
+package HttpClient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+type TLSOption func(cfg *tls.Config) error
+
+// 组装 tls.Config，配合 SetTlsClient 使用
+func NewTLSConfig(opts ...TLSOption) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func ClientCertPEM(certPEM, keyPEM []byte) TLSOption {
+	return func(cfg *tls.Config) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %v", err)
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+func ClientCertFile(certFile, keyFile string) TLSOption {
+	return func(cfg *tls.Config) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %v", err)
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+// PKCS#12 证书，例如微信支付的 apiclient_cert.p12（密码为商户号）
+func ClientCertPKCS12(data []byte, password string) TLSOption {
+	return func(cfg *tls.Config) error {
+		blocks, err := pkcs12.ToPEM(data, password)
+		if err != nil {
+			return fmt.Errorf("load pkcs12 certificate: %v", err)
+		}
+
+		var certPEM, keyPEM []byte
+		for _, block := range blocks {
+			if block.Type == "CERTIFICATE" {
+				certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+			} else if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+				keyPEM = append(keyPEM, pem.EncodeToMemory(block)...)
+			}
+		}
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("load pkcs12 certificate: %v", err)
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+func ClientCertPKCS12File(file string, password string) TLSOption {
+	return func(cfg *tls.Config) error {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return ClientCertPKCS12(data, password)(cfg)
+	}
+}
+
+// 信任的根证书，替换系统根证书
+func RootCAs(pemCerts ...[]byte) TLSOption {
+	return func(cfg *tls.Config) error {
+		if cfg.RootCAs == nil {
+			cfg.RootCAs = x509.NewCertPool()
+		}
+		for _, pemCert := range pemCerts {
+			if !cfg.RootCAs.AppendCertsFromPEM(pemCert) {
+				return errors.New("no valid certificate found in root CA pem")
+			}
+		}
+		return nil
+	}
+}
+
+func RootCAFile(files ...string) TLSOption {
+	return func(cfg *tls.Config) error {
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err := RootCAs(data)(cfg); err != nil {
+				return fmt.Errorf("%s: %v", file, err)
+			}
+		}
+		return nil
+	}
+}
+
+// 证书公钥固定，pins 为证书链中任一证书 SubjectPublicKeyInfo 的 sha256 base64 值，
+// 格式同 HPKP：可带 "sha256/" 前缀。InsecureSkipVerify 时只能固定叶子证书
+func PinSPKI(pins ...string) TLSOption {
+	return func(cfg *tls.Config) error {
+		allowed := map[string]bool{}
+		for _, pin := range pins {
+			pin = strings.TrimPrefix(pin, "sha256/")
+			if b, err := base64.StdEncoding.DecodeString(pin); err != nil || len(b) != sha256.Size {
+				return fmt.Errorf("invalid spki pin %q", pin)
+			}
+			allowed[pin] = true
+		}
+
+		//VerifyPeerCertificate 在会话恢复时不会执行，VerifyConnection 每次握手都会执行
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			certs := make([]*x509.Certificate, 0)
+			if len(cs.VerifiedChains) > 0 {
+				for _, chain := range cs.VerifiedChains {
+					certs = append(certs, chain...)
+				}
+			} else if len(cs.PeerCertificates) > 0 {
+				//InsecureSkipVerify 时没有验证过的证书链，服务端发送的其余证书不能证明和叶子证书有关，只比对叶子证书
+				certs = append(certs, cs.PeerCertificates[0])
+			}
+
+			got := make([]string, 0, len(certs))
+			for _, cert := range certs {
+				pin := SPKIPin(cert)
+				if allowed[pin] {
+					return nil
+				}
+				got = append(got, pin)
+			}
+
+			return &PinError{Expected: pins, Got: got}
+		}
+		return nil
+	}
+}
+
+func InsecureSkipVerify() TLSOption {
+	return func(cfg *tls.Config) error {
+		cfg.InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// 计算证书的 SPKI sha256 pin
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+type PinError struct {
+	Expected []string
+	Got      []string
+}
+
+func (e *PinError) Error() string {
+	return fmt.Sprintf("certificate pin mismatch: expected one of [%s], server presented [%s]",
+		strings.Join(e.Expected, ", "), strings.Join(e.Got, ", "))
+}
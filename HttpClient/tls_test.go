@@ -0,0 +1,213 @@
+package HttpClient_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+// 生成 CA 以及由它签发的客户端证书
+func newClientCert(t *testing.T) (caPEM, certPEM, keyPEM []byte) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "goutils-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTpl, caTpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "goutils-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newTLSServer(clientAuth tls.ClientAuthType, clientCAs *x509.CertPool) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: clientAuth, ClientCAs: clientCAs}
+	server.StartTLS()
+	return server
+}
+
+func serverPEM(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func TestTLSConfig_ClientCertPEM(t *testing.T) {
+	caPEM, certPEM, keyPEM := newClientCert(t)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(caPEM)
+	server := newTLSServer(tls.RequireAndVerifyClientCert, pool)
+	defer server.Close()
+
+	cfg, err := HttpClient.NewTLSConfig(
+		HttpClient.ClientCertPEM(certPEM, keyPEM),
+		HttpClient.RootCAs(serverPEM(server)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "goutils-client" {
+		t.Fatalf("unexpected peer %q", s)
+	}
+
+	//不带客户端证书时握手失败
+	cfg, _ = HttpClient.NewTLSConfig(HttpClient.RootCAs(serverPEM(server)))
+	if _, err := HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil); err == nil {
+		t.Fatal("expected handshake error without client certificate")
+	}
+}
+
+func TestTLSConfig_ClientCertPKCS12(t *testing.T) {
+	server := newTLSServer(tls.RequireAnyClientCert, nil)
+	defer server.Close()
+
+	if _, err := HttpClient.NewTLSConfig(HttpClient.ClientCertPKCS12File("testdata/client.p12", "wrong")); err == nil {
+		t.Fatal("expected error for wrong password")
+	}
+
+	cfg, err := HttpClient.NewTLSConfig(
+		HttpClient.ClientCertPKCS12File("testdata/client.p12", "secret"),
+		HttpClient.RootCAs(serverPEM(server)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "goutils-client" {
+		t.Fatalf("unexpected peer %q", s)
+	}
+}
+
+func TestTLSConfig_PinSPKI(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	pin := HttpClient.SPKIPin(server.Certificate())
+	cfg, err := HttpClient.NewTLSConfig(HttpClient.RootCAs(serverPEM(server)), HttpClient.PinSPKI("sha256/"+pin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	other := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	cfg, _ = HttpClient.NewTLSConfig(HttpClient.InsecureSkipVerify(), HttpClient.PinSPKI(other))
+	_, err = HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil)
+	var pinErr *HttpClient.PinError
+	if !errors.As(err, &pinErr) || pinErr.Got[0] != pin {
+		t.Fatalf("expected pin mismatch error, got %v", err)
+	}
+
+	if _, err := HttpClient.NewTLSConfig(HttpClient.PinSPKI("not-a-pin")); err == nil {
+		t.Fatal("expected invalid pin error")
+	}
+}
+
+// 恢复会话时同样要校验固定的公钥
+func TestTLSConfig_PinSPKIResumedSession(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strconv.FormatBool(r.TLS.DidResume)))
+	}))
+	defer server.Close()
+
+	cache := tls.NewLRUClientSessionCache(1)
+	cfg, _ := HttpClient.NewTLSConfig(HttpClient.InsecureSkipVerify())
+	cfg.ClientSessionCache = cache
+	if _, err := HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _ = HttpClient.NewTLSConfig(HttpClient.InsecureSkipVerify(), HttpClient.PinSPKI(HttpClient.SPKIPin(server.Certificate())))
+	cfg.ClientSessionCache = cache
+	resp, err := HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := resp.Content(); s != "true" {
+		t.Fatal("expected a resumed session")
+	}
+
+	cfg, _ = HttpClient.NewTLSConfig(HttpClient.InsecureSkipVerify(), HttpClient.PinSPKI("47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="))
+	cfg.ClientSessionCache = cache
+	_, err = HttpClient.NewRequest().SetTlsClient(cfg).GET(server.URL, nil)
+	var pinErr *HttpClient.PinError
+	if !errors.As(err, &pinErr) {
+		t.Fatalf("expected pin mismatch error on resumed session, got %v", err)
+	}
+}
+
+// 跳过证书验证时，伪造的叶子证书后面附带被固定的中间证书不能通过校验
+func TestTLSConfig_PinSPKIInsecureLeafOnly(t *testing.T) {
+	caPEM, certPEM, _ := newClientCert(t)
+	caBlock, _ := pem.Decode(caPEM)
+	leafBlock, _ := pem.Decode(certPEM)
+	ca, _ := x509.ParseCertificate(caBlock.Bytes)
+	leaf, _ := x509.ParseCertificate(leafBlock.Bytes)
+
+	cfg, err := HttpClient.NewTLSConfig(HttpClient.InsecureSkipVerify(), HttpClient.PinSPKI(HttpClient.SPKIPin(ca)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf, ca}})
+	var pinErr *HttpClient.PinError
+	if !errors.As(err, &pinErr) || len(pinErr.Got) != 1 || pinErr.Got[0] != HttpClient.SPKIPin(leaf) {
+		t.Fatalf("expected pin mismatch error, got %v", err)
+	}
+
+	cfg, _ = HttpClient.NewTLSConfig(HttpClient.InsecureSkipVerify(), HttpClient.PinSPKI(HttpClient.SPKIPin(leaf)))
+	if err := cfg.VerifyConnection(tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf, ca}}); err != nil {
+		t.Fatal(err)
+	}
+}
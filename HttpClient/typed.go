@@ -0,0 +1,117 @@
+package HttpClient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// 响应状态码不是 2xx 时返回的错误
+type StatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	Response   *Response
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("goutils.HttpClient: unexpected status %s: %s", e.Status, truncate(e.Body, 256))
+}
+
+// 响应体无法解析为目标类型时返回的错误
+type DecodeError struct {
+	Err      error
+	Body     []byte
+	Response *Response
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("goutils.HttpClient: decode response: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		return string(b[:n]) + "..."
+	}
+	return string(b)
+}
+
+// 发送 GET 请求，并将 2xx 响应体解析为 T
+func GetJSON[T any](r *Request, reqUrl string, data Data) (T, error) {
+	return decodeJSON[T](r.GET(reqUrl, data))
+}
+
+// 发送 DELETE 请求，并将 2xx 响应体解析为 T
+func DeleteJSON[T any](r *Request, reqUrl string, data Data) (T, error) {
+	return decodeJSON[T](r.DELETE(reqUrl, data))
+}
+
+// 按 method 发送请求，Data 的处理与 GET/POST 等方法相同，并将 2xx 响应体解析为 T
+func DoJSON[T any](r *Request, method string, reqUrl string, data Data) (T, error) {
+	return decodeJSON[T](r.request(method, reqUrl, data))
+}
+
+// 作为类型参数时只检查状态码，忽略响应体
+type NoContent struct{}
+
+// 将 body 编码为 JSON 发送 POST 请求，并将 2xx 响应体解析为 Resp
+func PostJSON[Req any, Resp any](r *Request, reqUrl string, body Req) (Resp, error) {
+	return SendJSON[Req, Resp](r, http.MethodPost, reqUrl, body)
+}
+
+// 将 body 编码为 JSON 发送 PUT 请求，并将 2xx 响应体解析为 Resp
+func PutJSON[Req any, Resp any](r *Request, reqUrl string, body Req) (Resp, error) {
+	return SendJSON[Req, Resp](r, http.MethodPut, reqUrl, body)
+}
+
+// 将 body 编码为 JSON 按 method 发送，并将 2xx 响应体解析为 Resp，
+// 不会修改 Request 上已有的请求头和请求体
+func SendJSON[Req any, Resp any](r *Request, method string, reqUrl string, body Req) (Resp, error) {
+	var zero Resp
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return zero, err
+	}
+
+	return decodeJSON[Resp](r.sendJSON(method, reqUrl, b))
+}
+
+// 发送 JSON 请求体，Content-Type 始终为 JSON，其余请求头沿用 Request 的设置
+func (r *Request) sendJSON(method string, reqUrl string, body []byte) (*Response, error) {
+	headers := make(map[string]string, len(r.headers)+1)
+	for k, v := range r.headers {
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+	headers["Content-Type"] = "application/json;charset=utf-8"
+
+	return r.requestBody(method, reqUrl, headers, body)
+}
+
+func decodeJSON[T any](resp *Response, err error) (T, error) {
+	var v T
+	if err != nil {
+		return v, err
+	}
+
+	b, err := resp.Body()
+	if err != nil {
+		return v, err
+	}
+
+	if resp.StatusCode() < 200 || resp.StatusCode() > 299 {
+		return v, &StatusError{StatusCode: resp.StatusCode(), Status: resp.Resp.Status, Body: b, Response: resp}
+	}
+
+	if _, ok := any(v).(NoContent); ok || len(b) == 0 {
+		return v, nil
+	}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		return v, &DecodeError{Err: err, Body: b, Response: resp}
+	}
+	return v, nil
+}
@@ -0,0 +1,124 @@
+package HttpClient_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+type user struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			w.Write([]byte(`{"id":1,"name":"` + r.URL.Query().Get("name") + `"}`))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		case "/broken":
+			w.Write([]byte(`{"id":"x"}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	u, err := HttpClient.GetJSON[user](HttpClient.NewRequest(), server.URL+"/user", HttpClient.Data{"name": "goutils"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != (user{Id: 1, Name: "goutils"}) {
+		t.Fatalf("unexpected user %+v", u)
+	}
+
+	_, err = HttpClient.GetJSON[user](HttpClient.NewRequest(), server.URL+"/missing", nil)
+	var statusErr *HttpClient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || string(statusErr.Body) != `{"error":"not found"}` {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+
+	_, err = HttpClient.GetJSON[user](HttpClient.NewRequest(), server.URL+"/broken", nil)
+	var decodeErr *HttpClient.DecodeError
+	if !errors.As(err, &decodeErr) || string(decodeErr.Body) != `{"id":"x"}` {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+
+	m, err := HttpClient.DeleteJSON[map[string]interface{}](HttpClient.NewRequest(), server.URL+"/empty", nil)
+	if err != nil || m != nil {
+		t.Fatalf("expected zero value for 204, got %v %v", m, err)
+	}
+}
+
+func TestPostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		w.Write([]byte(`{"method":"` + r.Method + `","type":"` + r.Header.Get("Content-Type") + `","token":"` + r.Header.Get("X-Token") + `","body":` + string(b) + `}`))
+	}))
+	defer server.Close()
+
+	type result struct {
+		Method string `json:"method"`
+		Type   string `json:"type"`
+		Token  string `json:"token"`
+		Body   user   `json:"body"`
+	}
+
+	req := HttpClient.NewRequest().SetHeaders(map[string]string{"X-Token": "t"})
+	res, err := HttpClient.PostJSON[user, result](req, server.URL, user{Id: 2, Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Method != http.MethodPost || res.Token != "t" || res.Body != (user{Id: 2, Name: "a"}) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	res, err = HttpClient.PutJSON[*user, result](req, server.URL, &user{Id: 3})
+	if err != nil || res.Method != http.MethodPut || res.Body.Id != 3 {
+		t.Fatalf("unexpected result %+v %v", res, err)
+	}
+
+	//辅助函数不应改变 Request 原有的请求头和请求体
+	resp, err := req.POST(server.URL, HttpClient.Data{"a": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Headers().Get("X-Content-Type") == "application/json;charset=utf-8" {
+		t.Fatalf("Content-Type leaked into later requests: %q", resp.Headers().Get("X-Content-Type"))
+	}
+
+	//POST 留下的表单 Content-Type 不能覆盖 JSON
+	res, err = HttpClient.PostJSON[user, result](req, server.URL, user{Id: 4})
+	if err != nil || res.Type != "application/json;charset=utf-8" || res.Body.Id != 4 {
+		t.Fatalf("unexpected result %+v %v", res, err)
+	}
+}
+
+func TestDoJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			w.Write([]byte("patched, not json"))
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer server.Close()
+
+	if _, err := HttpClient.DoJSON[HttpClient.NoContent](HttpClient.NewRequest(), http.MethodPatch, server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := HttpClient.DoJSON[HttpClient.NoContent](HttpClient.NewRequest(), http.MethodPost, server.URL, nil)
+	var statusErr *HttpClient.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusConflict {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+}
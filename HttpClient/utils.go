@@ -1,9 +1,31 @@
 package HttpClient
 
-import "github.com/json-iterator/go"
+import (
+	"sort"
+
+	"github.com/json-iterator/go"
+)
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
 func Json() jsoniter.API {
 	return json
-}
\ No newline at end of file
+}
+
+func sortedKeys(data Data) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedFileKeys(files File) []string {
+	keys := make([]string, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,255 @@
+package HttpClient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	TextMessage   = websocket.TextMessage
+	BinaryMessage = websocket.BinaryMessage
+)
+
+var ErrWebSocketClosed = errors.New("websocket closed")
+
+type WebSocket struct {
+	conn    *websocket.Conn
+	resp    *Response
+	writeMu sync.Mutex
+	closeMu sync.Mutex
+	closed  bool
+	//读锁，同一时间只有一个协程读取，关闭时也要拿到它才能自己读取关闭帧
+	readMu  chan struct{}
+	done    chan struct{}
+	timeout time.Duration
+}
+
+// 使用 Request 上的代理、TLS、请求头、Cookie 和 BasicAuth 完成 WebSocket 握手
+func (r *Request) Dial(ctx context.Context, wsUrl string) (*WebSocket, error) {
+	if wsUrl == "" {
+		return nil, errors.New("parameter url is required")
+	}
+
+	u, err := url.Parse(wsUrl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+
+	//和 buildClient 一样在锁内读取 Transport，TLS 配置复制一份交给 Dialer
+	r.clientMu.Lock()
+	if _, err := r.getTransport(); err != nil {
+		r.clientMu.Unlock()
+		return nil, err
+	}
+	dialer := &websocket.Dialer{
+		NetDialContext:   r.transport.DialContext,
+		Proxy:            r.transport.Proxy,
+		TLSClientConfig:  r.transport.TLSClientConfig.Clone(),
+		HandshakeTimeout: time.Second * r.timeout,
+	}
+	r.clientMu.Unlock()
+
+	//Cookie Jar 按 http(s) 地址保存 Cookie
+	cookieUrl := *u
+	switch u.Scheme {
+	case "ws":
+		cookieUrl.Scheme = "http"
+	case "wss":
+		cookieUrl.Scheme = "https"
+	}
+
+	r.method = http.MethodGet
+	r.url = u.String()
+	r.data = nil
+	defer r.log()
+
+	//借助 http.Request 生成与普通请求一致的请求头
+	req, err := http.NewRequest(r.method, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.initHeaders(req)
+	//不设置 Dialer.Jar，否则它会被请求头中的 Cookie 覆盖，统一由请求头携带
+	if r.jar != nil {
+		for _, cookie := range r.jar.Cookies(&cookieUrl) {
+			req.AddCookie(cookie)
+		}
+	}
+	r.initCookies(req)
+	r.initBasicAuth(req)
+
+	conn, res, err := dialer.DialContext(ctx, r.url, req.Header)
+	if r.jar != nil && res != nil {
+		if cookies := res.Cookies(); len(cookies) > 0 {
+			r.jar.SetCookies(&cookieUrl, cookies)
+		}
+	}
+	if err != nil {
+		if res != nil {
+			return nil, &HandshakeError{Response: &Response{url: r.url, Resp: res}, err: err}
+		}
+		return nil, err
+	}
+
+	return &WebSocket{
+		conn:    conn,
+		resp:    &Response{url: r.url, Resp: res},
+		readMu:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		timeout: 10 * time.Second,
+	}, nil
+}
+
+// 握手失败时携带服务端的响应
+type HandshakeError struct {
+	Response *Response
+	err      error
+}
+
+func (e *HandshakeError) Error() string {
+	return "websocket handshake failed with status " + e.Response.Resp.Status + ": " + e.err.Error()
+}
+
+func (ws *WebSocket) Conn() *websocket.Conn {
+	return ws.conn
+}
+
+func (ws *WebSocket) Response() *Response {
+	return ws.resp
+}
+
+// 每隔 interval 发送 ping，超过两个周期没有收到任何消息或 pong 则读操作超时返回
+func (ws *WebSocket) KeepAlive(interval time.Duration) *WebSocket {
+	wait := interval * 2
+	ws.conn.SetReadDeadline(time.Now().Add(wait))
+	ws.conn.SetPongHandler(func(string) error {
+		return ws.conn.SetReadDeadline(time.Now().Add(wait))
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ws.done:
+				return
+			case <-ticker.C:
+				if err := ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.timeout)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return ws
+}
+
+func (ws *WebSocket) ReadMessage() (int, []byte, error) {
+	ws.readMu <- struct{}{}
+	messageType, b, err := ws.conn.ReadMessage()
+	<-ws.readMu
+	if err != nil {
+		closeErr := websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway)
+		ws.shutdown()
+		if closeErr {
+			return messageType, nil, ErrWebSocketClosed
+		}
+		return messageType, nil, err
+	}
+	return messageType, b, nil
+}
+
+func (ws *WebSocket) ReadText() (string, error) {
+	_, b, err := ws.ReadMessage()
+	return string(b), err
+}
+
+func (ws *WebSocket) ReadJson(v interface{}) error {
+	_, b, err := ws.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (ws *WebSocket) WriteMessage(messageType int, data []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	ws.conn.SetWriteDeadline(time.Now().Add(ws.timeout))
+	return ws.conn.WriteMessage(messageType, data)
+}
+
+func (ws *WebSocket) WriteText(s string) error {
+	return ws.WriteMessage(TextMessage, []byte(s))
+}
+
+func (ws *WebSocket) WriteJson(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(TextMessage, b)
+}
+
+// 发送关闭帧，等待服务端回应关闭帧后关闭底层连接
+func (ws *WebSocket) Close() error {
+	return ws.CloseWithReason(websocket.CloseNormalClosure, "")
+}
+
+func (ws *WebSocket) CloseWithReason(code int, reason string) error {
+	ws.closeMu.Lock()
+	if ws.closed {
+		ws.closeMu.Unlock()
+		return nil
+	}
+	msg := websocket.FormatCloseMessage(code, reason)
+	err := ws.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(ws.timeout))
+	ws.closeMu.Unlock()
+	if err != nil && err != websocket.ErrCloseSent {
+		ws.shutdown()
+		return err
+	}
+
+	//读协程收到关闭帧后会关闭连接，读协程退出或本来就没有读协程时，拿到读锁自己读取关闭帧
+	timer := time.NewTimer(ws.timeout)
+	defer timer.Stop()
+	select {
+	case ws.readMu <- struct{}{}:
+		ws.conn.SetReadDeadline(time.Now().Add(ws.timeout))
+		for {
+			if _, _, err := ws.conn.NextReader(); err != nil {
+				break
+			}
+		}
+		<-ws.readMu
+	case <-ws.done:
+	case <-timer.C:
+	}
+
+	ws.shutdown()
+	return nil
+}
+
+func (ws *WebSocket) shutdown() {
+	ws.closeMu.Lock()
+	defer ws.closeMu.Unlock()
+	if ws.closed {
+		return
+	}
+	ws.closed = true
+	close(ws.done)
+	ws.conn.Close()
+}
@@ -0,0 +1,197 @@
+package HttpClient_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+func TestRequest_Dial(t *testing.T) {
+	var pings int32
+	closed := make(chan error, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		cookie, _ := r.Cookie("sid")
+		if r.Header.Get("X-Token") != "abc" || user != "u" || pass != "p" || cookie == nil || cookie.Value != "1" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.SetPingHandler(func(data string) error {
+			atomic.AddInt32(&pings, 1)
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+
+		for {
+			mt, b, err := conn.ReadMessage()
+			if err != nil {
+				closed <- err
+				return
+			}
+			conn.WriteMessage(mt, []byte(strings.ToUpper(string(b))))
+		}
+	}))
+	defer server.Close()
+
+	ws, err := HttpClient.NewRequest().
+		AddHeaders(map[string]string{"X-Token": "abc"}).
+		AddCookies(map[string]string{"sid": "1"}).
+		SetBasicAuth("u", "p").
+		Dial(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.KeepAlive(20 * time.Millisecond)
+
+	if err := ws.WriteText("hello"); err != nil {
+		t.Fatal(err)
+	}
+	s, err := ws.ReadText()
+	if err != nil || s != "HELLO" {
+		t.Fatalf("unexpected reply %q, %v", s, err)
+	}
+
+	if err := ws.WriteJson(map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	var v map[string]string
+	if err := ws.ReadJson(&v); err != nil || v["A"] != "B" {
+		t.Fatalf("unexpected reply %v, %v", v, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if atomic.LoadInt32(&pings) == 0 {
+		t.Fatal("expected keepalive pings")
+	}
+
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-closed:
+		if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			t.Fatalf("expected normal closure, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("server did not observe close")
+	}
+}
+
+func TestRequest_DialHandshakeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := HttpClient.NewRequest().Dial(context.Background(), server.URL)
+	herr, ok := err.(*HttpClient.HandshakeError)
+	if !ok || herr.Response.StatusCode() != http.StatusForbidden {
+		t.Fatalf("expected handshake error, got %v", err)
+	}
+}
+
+func TestWebSocket_CloseWhileReading(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			mt, b, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteMessage(mt, b)
+		}
+	}))
+	defer server.Close()
+
+	//读协程和 Close 同时开始，不能出现两个协程同时读取
+	for i := 0; i < 20; i++ {
+		ws, err := HttpClient.NewRequest().Dial(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws.WriteText("ping")
+
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			for {
+				if _, _, err := ws.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		start := time.Now()
+		if err := ws.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("close waited for the timeout")
+		}
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("reader did not stop")
+		}
+	}
+}
+
+// Cookie Jar 中的 Cookie 和 AddCookies 的 Cookie 都要带上
+func TestRequest_DialCookieJar(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jar, _ := r.Cookie("jar")
+		sid, _ := r.Cookie("sid")
+		if jar == nil || jar.Value != "1" || sid == nil || sid.Value != "1" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, http.Header{"Set-Cookie": {"token=2"}})
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	jar, _ := cookiejar.New(nil)
+	jar.SetCookies(u, []*http.Cookie{{Name: "jar", Value: "1"}})
+
+	ws, err := HttpClient.NewRequest().
+		SetCookieJar(jar).
+		AddCookies(map[string]string{"sid": "1"}).
+		Dial(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Close()
+
+	for _, cookie := range jar.Cookies(u) {
+		if cookie.Name == "token" && cookie.Value == "2" {
+			return
+		}
+	}
+	t.Fatalf("handshake cookies were not saved, got %v", jar.Cookies(u))
+}
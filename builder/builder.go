@@ -15,7 +15,9 @@ type SQLBuilder struct {
 	_insertAll       string
 	_update          string
 	_delete          string
-	_limit           string
+	_hasLimit        bool
+	_offset          interface{}
+	_num             interface{}
 	_orderBy         string
 	_groupBy         string
 	_table           string
@@ -28,7 +30,19 @@ type SQLBuilder struct {
 	_whereParams     []interface{}
 	_joinParams      []interface{}
 	_havingParams    []interface{}
-	_limitParams     []interface{}
+	_selectParams    []interface{}
+	_with            string
+	_withRecursive   bool
+	_withParams      []interface{}
+	_fromParams      []interface{}
+	_union           string
+	_unionParams     []interface{}
+	_upsert          int
+	_conflict        []string
+	_upsertUpdate    []string
+	_upsertAlias     string
+	dialect          Dialect
+	err              error
 }
 
 var (
@@ -36,7 +50,6 @@ var (
 	ErrInsertStatement = errors.New("insert statement empty")
 	ErrUpdateStatement = errors.New("update statement empty")
 	ErrElementStatement = errors.New("element type error")
-	m                  reflect.Type
 	ignoreKey = "Id"
 )
 
@@ -44,18 +57,61 @@ func NewSQLBuilder() *SQLBuilder {
 	return &SQLBuilder{}
 }
 
+//设置 SQL 方言，默认 MySQL
+func (sb *SQLBuilder) SetDialect(d Dialect) *SQLBuilder {
+	sb.dialect = d
+	return sb
+}
+
+func (sb *SQLBuilder) getDialect() Dialect {
+	if sb.dialect == nil {
+		return MySQL
+	}
+	return sb.dialect
+}
+
+func (sb *SQLBuilder) limit() (string, []interface{}) {
+	if !sb._hasLimit {
+		return "", nil
+	}
+	args := limitArgs{offset: sb._offset, num: sb._num, hasOrderBy: sb._orderBy != ""}
+	return args.mark(), []interface{}{args}
+}
+
 //SELECT `t1`.`name`,`t1`.`age`,`t2`.`teacher`,`t3`.`address` FROM `test` as t1 LEFT
 //JOIN `test2` as `t2` ON `t1`.`class` = `t2`.`class` INNER JOIN `test3` as t3 ON
 //`t1`.`school` = `t3`.`school` WHERE `t1`.`age` >= 20 GROUP BY `t1`.`age`
 //HAVING COUNT(`t1`.`age`) > 2 ORDER BY `t1`.`age` DESC LIMIT ?,?
 
 func (sb *SQLBuilder) GetQuerySql() (string, error) {
+	sql, err := sb.querySql()
+	if err != nil {
+		return "", err
+	}
+	return sb.render(sql), nil
+}
+
+//未渲染的查询语句，占位符为 ?，标识符和分页为标记，嵌入其他语句时使用
+func (sb *SQLBuilder) querySql() (string, error) {
+	if sb.err != nil {
+		return "", sb.err
+	}
+
 	if sb._table == "" {
 		return "", ErrTableEmpty
 	}
 
 	var buf strings.Builder
 
+	if sb._with != "" {
+		buf.WriteString("WITH ")
+		if sb._withRecursive {
+			buf.WriteRune(recursiveMark)
+		}
+		buf.WriteString(sb._with)
+		buf.WriteString(" ")
+	}
+
 	buf.WriteString("SELECT ")
 	if sb._select != "" {
 		buf.WriteString(sb._select)
@@ -86,46 +142,64 @@ func (sb *SQLBuilder) GetQuerySql() (string, error) {
 		buf.WriteString(sb._having)
 	}
 
+	if sb._union != "" {
+		buf.WriteString(" ")
+		buf.WriteString(sb._union)
+	}
+
 	if sb._orderBy != "" {
 		buf.WriteString(" ")
 		buf.WriteString(sb._orderBy)
 	}
 
-	if sb._limit != "" {
+	if limit, _ := sb.limit(); limit != "" {
 		buf.WriteString(" ")
-		buf.WriteString(sb._limit)
+		buf.WriteString(limit)
 	}
 
 	return buf.String(), nil
 }
 
 func (sb *SQLBuilder) GetQueryParams() []interface{} {
+	return sb.args(sb.queryParams())
+}
+
+func (sb *SQLBuilder) queryParams() []interface{} {
 	Params := []interface{}{}
+	Params = append(Params, sb._withParams...)
+	Params = append(Params, sb._selectParams...)
+	Params = append(Params, sb._fromParams...)
 	Params = append(Params, sb._joinParams...)
 	Params = append(Params, sb._whereParams...)
 	Params = append(Params, sb._havingParams...)
-	Params = append(Params, sb._limitParams...)
+	Params = append(Params, sb._unionParams...)
+	_, limitParams := sb.limit()
+	Params = append(Params, limitParams...)
 
 	return Params
 }
 
+//列名按方言引用，支持 t1.name、t1.* 和 name AS alias，表达式请使用 SelectRaw
 func (sb *SQLBuilder) Select(cols ...string) *SQLBuilder {
-	var buf strings.Builder
+	sb._select = strings.Join(sb.idents(cols), ",")
+	sb._selectParams = nil
+	return sb
+}
 
-	for key, col := range cols {
-		buf.WriteString(col)
-		if key != len(cols)-1 {
-			buf.WriteString(",")
-		}
+//追加原样输出的查询列，如 COUNT(*) AS total，不做引用和校验
+func (sb *SQLBuilder) SelectRaw(s string, values ...interface{}) *SQLBuilder {
+	if sb._select != "" {
+		sb._select += ","
 	}
-
-	sb._select = buf.String()
-
+	sb._select += s
+	sb._selectParams = append(sb._selectParams, values...)
 	return sb
 }
 
+//支持 table、db.table 和 table AS t1、table t1
 func (sb *SQLBuilder) Table(table string) *SQLBuilder {
-	sb._table = table
+	sb._table = sb.ident(table)
+	sb._fromParams = nil
 	return sb
 }
 
@@ -135,7 +209,7 @@ func (sb *SQLBuilder) Insert(fields []string, values ...interface{}) *SQLBuilder
 	buf.WriteString("(")
 
 	for key, field := range fields {
-		buf.WriteString(field)
+		buf.WriteString(sb.ident(field))
 		if key != len(fields)-1 {
 			buf.WriteString(",")
 		}
@@ -160,6 +234,10 @@ func (sb *SQLBuilder) Insert(fields []string, values ...interface{}) *SQLBuilder
 }
 
 func (sb *SQLBuilder) GetInsertSql() (string, error) {
+	if sb.err != nil {
+		return "", sb.err
+	}
+
 	if sb._table == "" {
 		return "", ErrTableEmpty
 	}
@@ -168,13 +246,7 @@ func (sb *SQLBuilder) GetInsertSql() (string, error) {
 		return "", ErrInsertStatement
 	}
 
-	var buf strings.Builder
-	buf.WriteString("INSERT INTO ")
-	buf.WriteString(sb._table)
-	buf.WriteString(" ")
-	buf.WriteString(sb._insert)
-
-	return buf.String(), nil
+	return sb.insertSql(sb._insert)
 }
 
 func (sb *SQLBuilder) GetInsertParams() []interface{} {
@@ -185,7 +257,7 @@ func (sb *SQLBuilder) Update(fields []string, values ...interface{}) *SQLBuilder
 	var buf strings.Builder
 
 	for key, val := range fields {
-		buf.WriteString(val)
+		buf.WriteString(sb.ident(val))
 		buf.WriteString(" = ")
 		buf.WriteString("?")
 		if key != len(fields)-1 {
@@ -203,6 +275,10 @@ func (sb *SQLBuilder) Update(fields []string, values ...interface{}) *SQLBuilder
 }
 
 func (sb *SQLBuilder) GetUpdateSql() (string, error) {
+	if sb.err != nil {
+		return "", sb.err
+	}
+
 	if sb._table == "" {
 		return "", ErrTableEmpty
 	}
@@ -222,17 +298,21 @@ func (sb *SQLBuilder) GetUpdateSql() (string, error) {
 		buf.WriteString(sb._where)
 	}
 
-	return buf.String(), nil
+	return sb.render(buf.String()), nil
 }
 
 func (sb *SQLBuilder) GetUpdateParams() []interface{} {
 	Params := []interface{}{}
 	Params = append(Params, sb._updateParams...)
 	Params = append(Params, sb._whereParams...)
-	return Params
+	return sb.args(Params)
 }
 
 func (sb *SQLBuilder) GetDeleteSql() (string, error) {
+	if sb.err != nil {
+		return "", sb.err
+	}
+
 	if sb._table == "" {
 		return "", ErrTableEmpty
 	}
@@ -246,11 +326,11 @@ func (sb *SQLBuilder) GetDeleteSql() (string, error) {
 		buf.WriteString(sb._where)
 	}
 
-	return buf.String(), nil
+	return sb.render(buf.String()), nil
 }
 
 func (sb *SQLBuilder) GetDeleteParams() []interface{} {
-	return sb._whereParams
+	return sb.args(sb._whereParams)
 }
 
 func (sb *SQLBuilder) Where(field, condition string, value interface{}) *SQLBuilder {
@@ -270,31 +350,15 @@ func (sb *SQLBuilder) WhereOrRaw(s string, values []interface{}) *SQLBuilder {
 }
 
 func (sb *SQLBuilder) where(operator string, field string, condition string, value interface{}) *SQLBuilder {
-	var buf strings.Builder
-
-	buf.WriteString(sb._where)
-
-	if buf.Len() == 0 {
-		buf.WriteString("WHERE ")
-	} else {
-		buf.WriteString(" ")
-		buf.WriteString(operator)
-		buf.WriteString(" ")
-	}
-
-	buf.WriteString(field)
-	buf.WriteString(" ")
-	buf.WriteString(condition)
-	buf.WriteString(" ")
-	buf.WriteString("?")
-
-	sb._where = buf.String()
-	sb._whereParams = append(sb._whereParams, value)
-
-	return sb
+	return sb.addWhere(operator, sb.compare(field, condition), value)
 }
 
 func (sb *SQLBuilder) whereRaw(operator string, s string, values []interface{}) *SQLBuilder {
+	return sb.addWhere(operator, s, values...)
+}
+
+//追加一个条件表达式，第一个条件前加 WHERE
+func (sb *SQLBuilder) addWhere(operator string, expr string, values ...interface{}) *SQLBuilder {
 	var buf strings.Builder
 	buf.WriteString(sb._where)
 
@@ -306,16 +370,24 @@ func (sb *SQLBuilder) whereRaw(operator string, s string, values []interface{})
 		buf.WriteString(" ")
 	}
 
-	buf.WriteString(s)
+	buf.WriteString(expr)
 	sb._where = buf.String()
-
-	for _, value := range values {
-		sb._whereParams = append(sb._whereParams, value)
-	}
+	sb._whereParams = append(sb._whereParams, values...)
 
 	return sb
 }
 
+//field condition ?
+func (sb *SQLBuilder) compare(field string, condition string) string {
+	var buf strings.Builder
+	buf.WriteString(sb.ident(field))
+	buf.WriteString(" ")
+	buf.WriteString(sb.operator(condition))
+	buf.WriteString(" ")
+	buf.WriteString("?")
+	return buf.String()
+}
+
 func (sb *SQLBuilder) WhereIn(field string, value []interface{}) *SQLBuilder {
 	return sb.whereIn("AND", "IN", field, value)
 }
@@ -333,69 +405,74 @@ func (sb *SQLBuilder) WhereOrNotIn(field string, value []interface{}) *SQLBuilde
 }
 
 func (sb *SQLBuilder) whereIn(operator string, condition string, field string, values []interface{}) *SQLBuilder {
-	var buf strings.Builder
-	buf.WriteString(sb._where)
+	return sb.addWhere(operator, sb.in(field, condition, len(values)), values...)
+}
 
-	if buf.Len() == 0 {
-		buf.WriteString("WHERE ")
-	} else {
-		buf.WriteString(" ")
-		buf.WriteString(operator)
-		buf.WriteString(" ")
+//field IN (?,?,...)，空列表时 IN 恒为假，NOT IN 恒为真
+func (sb *SQLBuilder) in(field string, condition string, n int) string {
+	f := sb.ident(field)
+	if n == 0 {
+		if condition == "IN" {
+			return "1 = 0"
+		}
+		return "1 = 1"
 	}
 
-	buf.WriteString(field)
+	var buf strings.Builder
+	buf.WriteString(f)
 	buf.WriteString(" ")
 	buf.WriteString(condition)
 	buf.WriteString(" ")
 	buf.WriteString("(")
-	for i := 0; i < len(values); i++ {
+	for i := 0; i < n; i++ {
 		buf.WriteString("?")
-		if i != len(values)-1 {
+		if i != n-1 {
 			buf.WriteString(",")
 		}
 	}
 	buf.WriteString(")")
-
-	sb._where = buf.String()
-
-	for _, val := range values {
-		sb._whereParams = append(sb._whereParams, val)
-	}
-
-	return sb
+	return buf.String()
 }
 
+//分页语法由方言决定，如 MySQL 的 LIMIT ?,?，PostgreSQL 的 LIMIT ? OFFSET ?
 func (sb *SQLBuilder) Limit(offset, num interface{}) *SQLBuilder {
-	var buf strings.Builder
-	buf.WriteString("LIMIT ?,?")
-	sb._limit = buf.String()
-	sb._limitParams = append(sb._limitParams, offset, num)
+	sb._hasLimit = true
+	sb._offset = offset
+	sb._num = num
 	return sb
 }
 
+//order 只能是 ASC、DESC 或空字符串
 func (sb *SQLBuilder) OrderBy(order string, fields ...string) *SQLBuilder {
+	direction := strings.ToUpper(strings.TrimSpace(order))
+	if direction != "" && direction != "ASC" && direction != "DESC" {
+		sb.setErr(fmt.Errorf("%w: %q", ErrOrderDirection, order))
+	}
+
 	var buf strings.Builder
 	buf.WriteString("ORDER BY ")
-	for key, val := range fields {
-		buf.WriteString(val)
-		if key != len(fields)-1 {
-			buf.WriteString(",")
-		}
-	}
+	buf.WriteString(strings.Join(sb.idents(fields), ","))
 
-	buf.WriteString(" ")
-	buf.WriteString(order)
+	if direction != "" {
+		buf.WriteString(" ")
+		buf.WriteString(direction)
+	}
 
 	sb._orderBy = buf.String()
 
 	return sb
 }
 
+//原样输出的排序，如 FIELD(id, 3, 1, 2)
+func (sb *SQLBuilder) OrderByRaw(s string) *SQLBuilder {
+	sb._orderBy = "ORDER BY " + s
+	return sb
+}
+
 func (sb *SQLBuilder) GroupBy(field string) *SQLBuilder {
 	var buf strings.Builder
 	buf.WriteString("GROUP BY ")
-	buf.WriteString(field)
+	buf.WriteString(sb.ident(field))
 
 	sb._groupBy = buf.String()
 	return sb
@@ -447,6 +524,7 @@ func (sb *SQLBuilder) havingRaw(operator string, s string, values ...interface{}
 	return sb
 }
 
+//field 为标识符或 COUNT(*)、SUM(field)、COUNT(DISTINCT field) 等聚合函数，其他表达式使用 HavingRaw
 func (sb *SQLBuilder) Having(field string, condition string, value interface{}) *SQLBuilder {
 	return sb.having("AND", field, condition, value)
 }
@@ -471,9 +549,9 @@ func (sb *SQLBuilder) having(operator string, field string, condition string, va
 		buf.WriteString(" ")
 	}
 
-	buf.WriteString(field)
+	buf.WriteString(sb.aggregateIdent(field))
 	buf.WriteString(" ")
-	buf.WriteString(condition)
+	buf.WriteString(sb.operator(condition))
 	buf.WriteString(" ")
 	buf.WriteString("?")
 
@@ -489,7 +567,7 @@ func (sb *SQLBuilder) InsertAll(fields []string, values ...[]interface{}) *SQLBu
 	buf.WriteString("(")
 
 	for key, field := range fields {
-		buf.WriteString(field)
+		buf.WriteString(sb.ident(field))
 		if key != len(fields)-1 {
 			buf.WriteString(",")
 		}
@@ -520,6 +598,10 @@ func (sb *SQLBuilder) InsertAll(fields []string, values ...[]interface{}) *SQLBu
 }
 
 func (sb *SQLBuilder) GetInsertAllSql() (string, error) {
+	if sb.err != nil {
+		return "", sb.err
+	}
+
 	if sb._table == "" {
 		return "", ErrTableEmpty
 	}
@@ -528,13 +610,24 @@ func (sb *SQLBuilder) GetInsertAllSql() (string, error) {
 		return "", ErrInsertStatement
 	}
 
+	return sb.insertSql(sb._insertAll)
+}
+
+//冲突处理见 OnConflict、DoUpdate、DoNothing 和 InsertIgnore
+func (sb *SQLBuilder) insertSql(values string) (string, error) {
+	head, tail, err := sb.upsertClause()
+	if err != nil {
+		return "", err
+	}
+
 	var buf strings.Builder
-	buf.WriteString("INSERT INTO ")
+	buf.WriteString(head)
 	buf.WriteString(sb._table)
 	buf.WriteString(" ")
-	buf.WriteString(sb._insertAll)
+	buf.WriteString(values)
+	buf.WriteString(tail)
 
-	return buf.String(), nil
+	return sb.render(buf.String()), nil
 }
 
 func (sb *SQLBuilder) GetInsertAllParams() []interface{} {
@@ -548,143 +641,131 @@ func (sb *SQLBuilder) GetInsertAllParams() []interface{} {
 func (sb *SQLBuilder) InsertAllModel(models interface{}) (string,error) {
 	fields,values,err := sb.reflectElementInfo(models)
 	if err != nil {
-		return "",nil
+		return "",err
 	}
 
 	return sb.InsertAll(fields,values...).GetInsertAllSql()
 }
 
+//字段规则和 ScanAll 相同，嵌入结构体展开其字段，再按标签跳过 Id 等字段
 func (sb *SQLBuilder) reflectElementInfo(elem interface{}) ([]string,[][]interface{},error) {
-	m = reflect.TypeOf(elem)
-	values := make([]interface{}, 0)
-	allValues := make([][]interface{}, 0)
-	v := reflect.ValueOf(elem)
-	switch m.Kind() {
+	v := reflect.Indirect(reflect.ValueOf(elem))
+	elems := make([]reflect.Value, 0)
+	switch v.Kind() {
 	case reflect.Slice, reflect.Array:
-		//fmt.Println(values)
-		m = reflect.TypeOf(elem).Elem() //获取slice或array内的元素类型
-		if m.Kind() == reflect.Ptr {
-			//如果是指针，则指向其所指的元素
-			m = m.Elem()
-		}
-
-		var e reflect.Value
 		for i := 0; i < v.Len(); i++ {
-			vals := []interface{}{}
-			if v.Index(i).Kind() == reflect.Ptr {
-				e = v.Index(i).Elem()
-			}else{
-				e = v.Index(i)
-			}
-
-			for i2 := 0; i2 < e.NumField(); i2++ {
-				fn := m.Field(i2).Name
-				tag := m.Field(i2).Tag.Get("gdb")
-				if getTagIsContinue(fn, tag) {
-					continue
-				}
-				vals = append(vals, e.FieldByName(fn).Interface())
-			}
-			allValues = append(allValues, vals)
+			elems = append(elems, reflect.Indirect(v.Index(i)))
 		}
-		break
-	case reflect.Ptr:
-		//如果是指针，则指向其所指的对象
-		m = reflect.TypeOf(elem).Elem()
-		values = getParams(v.Elem(), m)
-		allValues = append(allValues, values)
-		break
 	case reflect.Struct:
-		values = getParams(v, m)
-		allValues = append(allValues, values)
-		break
+		elems = append(elems, v)
 	default:
 		return nil,nil,ErrElementStatement
 	}
 
+	//获取slice或array内的元素类型，如果是指针，则指向其所指的元素
+	t := v.Type()
+	if v.Kind() != reflect.Struct {
+		t = t.Elem()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() != reflect.Struct {
+		return nil,nil,ErrElementStatement
+	}
+
+	columns := make([]structField, 0)
 	fields := make([]string, 0)
-	for i := 0; i < m.NumField(); i++ {
-		fn := m.Field(i).Name
-		tag := m.Field(i).Tag.Get("gdb")
-		fn = getFnForTag(fn, tag)
-		if getTagIsContinue(fn, tag) {
+	for _, f := range getStructInfo(t).list {
+		if f.tag.skip(f.name) {
 			continue
 		}
-		fields = append(fields, fmt.Sprintf("%s", fn))
+		columns = append(columns, f)
+		fields = append(fields, f.column)
 	}
 
-	return fields,allValues,nil
-}
-
-func getParams(v reflect.Value, m reflect.Type) []interface{} {
-	values := make([]interface{}, 0)
-	for i := 0; i < m.NumField(); i++ {
-		fn := m.Field(i).Name
-		if fn == "Id" {
-			continue
+	allValues := make([][]interface{}, 0, len(elems))
+	for _, e := range elems {
+		if !e.IsValid() {
+			return nil,nil,ErrElementStatement
+		}
+		values := make([]interface{}, 0, len(columns))
+		for _, f := range columns {
+			values = append(values, fieldValue(e, f.index).Interface())
 		}
-		values = append(values, v.FieldByName(fn).Interface())
+		allValues = append(allValues, values)
 	}
 
-	return values
+	return fields,allValues,nil
 }
 
-//根据标签处理字段名
-func getFnForTag(fn, tag string) string {
-	fn = getTagColumnName(fn, tag)
-	fn = getTagUnderLine(fn, tag)
-
-	return fn
+//gdb 标签，多个选项用 ; 分隔，如 `gdb:"column:userAge;underline;require"`
+type gdbTag struct {
+	column    string
+	underline bool
+	ignore    bool
+	require   bool
+	primary   bool
 }
 
-//是否跳过
-func getTagIsContinue(fn, tag string) bool {
-	if strings.Contains(tag, "ignore") {
-		return true
+//解析标签，选项名需完全一致
+func parseTag(tag string) gdbTag {
+	t := gdbTag{}
+	for _, opt := range strings.Split(tag, ";") {
+		name, value := opt, ""
+		if i := strings.Index(opt, ":"); i >= 0 {
+			name, value = opt[:i], strings.TrimSpace(opt[i+1:])
+		}
+		switch strings.TrimSpace(name) {
+		case "column":
+			t.column = value
+		case "underline":
+			t.underline = true
+		case "ignore":
+			t.ignore = true
+		case "require":
+			t.require = true
+		case "primary":
+			t.primary = true
+		}
 	}
+	return t
+}
 
-	if strings.Contains(tag, "require") {
-		return false
+//标签中的字段名，underline 在获取标签字段名之后处理
+func (t gdbTag) columnName(fn string) string {
+	if t.column != "" {
+		fn = t.column
 	}
-	//fmt.Println("tag",tag,"unignore", strings.Contains(tag, "unignore"))
-	if strings.Contains(ignoreKey, fn){
-		return true
+	if t.underline {
+		fn = camelToUnderline(fn)
 	}
-
-	return false
+	return fn
 }
 
-//获取标签中的字段名
-func getTagColumnName(fn, tag string) string {
-	tags := strings.Split(tag, ";")
-	for _, val := range tags {
-		if strings.Contains(val, "column") {
-			columnName := strings.Split(val, ":")[1]
-			return columnName
-		}
+//是否跳过，默认跳过 Id 字段
+func (t gdbTag) skip(fn string) bool {
+	if t.ignore {
+		return true
 	}
-
-	return fn
+	if t.require {
+		return false
+	}
+	return fn == ignoreKey
 }
 
-//字段名转下划线，在获取标签字段名之后处理
-func getTagUnderLine(fn, tag string) string  {
+//字段名转下划线
+func camelToUnderline(fn string) string {
 	var buffer bytes.Buffer
-	if strings.Contains(tag, "underline") {
-		//Camel2Case
-		for i, i2 := range fn {
-			if unicode.IsUpper(i2){
-				if i != 0 {
-					buffer.WriteString("_")
-				}
-
-				buffer.WriteString(string(unicode.ToLower(i2)))
-			}else{
-				buffer.WriteString(string(i2))
+	for i, i2 := range fn {
+		if unicode.IsUpper(i2) {
+			if i != 0 {
+				buffer.WriteString("_")
 			}
+			buffer.WriteString(string(unicode.ToLower(i2)))
+		} else {
+			buffer.WriteString(string(i2))
 		}
-		return buffer.String()
 	}
-
-	return fn
-}
\ No newline at end of file
+	return buffer.String()
+}
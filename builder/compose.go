@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 嵌套的 SQLBuilder 统一按外层的方言生成，参数按 WITH、SELECT、FROM、JOIN、WHERE、HAVING、UNION、LIMIT 的顺序排列
+
+// FROM (子查询) AS alias，会替换 Table 设置的表
+func (sb *SQLBuilder) FromSub(sub *SQLBuilder, alias string) *SQLBuilder {
+	sb._table, sb._fromParams = sb.subAs(sub, alias)
+	return sb
+}
+
+// 追加子查询作为查询列，(子查询) AS alias
+func (sb *SQLBuilder) SelectSub(sub *SQLBuilder, alias string) *SQLBuilder {
+	expr, params := sb.subAs(sub, alias)
+	return sb.SelectRaw(expr, params...)
+}
+
+// UNION 之后的 OrderBy 和 Limit 作用于合并后的结果
+func (sb *SQLBuilder) Union(sub *SQLBuilder) *SQLBuilder {
+	return sb.union("UNION", sub)
+}
+
+func (sb *SQLBuilder) UnionAll(sub *SQLBuilder) *SQLBuilder {
+	return sb.union("UNION ALL", sub)
+}
+
+func (sb *SQLBuilder) union(kind string, sub *SQLBuilder) *SQLBuilder {
+	sql, err := sub.querySql()
+	if err != nil {
+		sb.setErr(err)
+		return sb
+	}
+	//带排序或分页的子查询需要括号，否则会作用于整个结果
+	if sub._orderBy != "" || sub._hasLimit {
+		sql = string(unionMark) + sql + ")"
+	}
+
+	if sb._union != "" {
+		sb._union += " "
+	}
+	sb._union += kind + " " + sql
+	sb._unionParams = append(sb._unionParams, sub.queryParams()...)
+	return sb
+}
+
+// WITH name (columns) AS (子查询)，多次调用时按顺序追加
+func (sb *SQLBuilder) With(name string, sub *SQLBuilder, columns ...string) *SQLBuilder {
+	return sb.with(name, sub, columns)
+}
+
+// WITH RECURSIVE，子查询通常为 anchor.UnionAll(recursive)，recursive 中用 Table(name) 引用自身
+func (sb *SQLBuilder) WithRecursive(name string, sub *SQLBuilder, columns ...string) *SQLBuilder {
+	sb._withRecursive = true
+	return sb.with(name, sub, columns)
+}
+
+func (sb *SQLBuilder) with(name string, sub *SQLBuilder, columns []string) *SQLBuilder {
+	n, err := markPart(name)
+	if err != nil {
+		sb.setErr(fmt.Errorf("%w: %q", ErrUnsafeIdentifier, name))
+		return sb
+	}
+	sql, params := sb.sub(sub)
+
+	var buf strings.Builder
+	buf.WriteString(sb._with)
+	if buf.Len() != 0 {
+		buf.WriteString(", ")
+	}
+	buf.WriteString(n)
+	if len(columns) > 0 {
+		cols := make([]string, len(columns))
+		for i, column := range columns {
+			if cols[i], err = markPart(column); err != nil {
+				sb.setErr(fmt.Errorf("%w: %q", ErrUnsafeIdentifier, column))
+				return sb
+			}
+		}
+		buf.WriteString(" (")
+		buf.WriteString(strings.Join(cols, ","))
+		buf.WriteString(")")
+	}
+	buf.WriteString(" AS ")
+	buf.WriteString(sql)
+
+	sb._with = buf.String()
+	sb._withParams = append(sb._withParams, params...)
+	return sb
+}
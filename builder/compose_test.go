@@ -0,0 +1,183 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+func TestSQLBuilder_ParamOrder(t *testing.T) {
+	active := builder.NewSQLBuilder().Table("users").Select("id").Where("status", "=", "w1")
+	orders := builder.NewSQLBuilder().Table("orders").Select("user_id").Where("amount", ">", "f1")
+	count := builder.NewSQLBuilder().Table("logins l").SelectRaw("COUNT(*)").WhereRaw("l.user_id = o.user_id", nil).Where("l.ok", "=", "s1")
+	shops := builder.NewSQLBuilder().Table("shops").Select("id").Where("open", "=", "j1")
+	archived := builder.NewSQLBuilder().Table("archive").Select("user_id").SelectRaw("?", "u1").Where("year", "=", "u2").OrderBy("DESC", "year").Limit(0, "u3")
+
+	sb := builder.NewSQLBuilder().
+		With("a", active).
+		Select("o.user_id").
+		SelectSub(count, "logins").
+		FromSub(orders, "o").
+		JoinSub(shops, "s", func(c *builder.Cond) {
+			c.On("s.id", "=", "o.user_id").Where("s.kind", "=", "j2")
+		}).
+		WhereInSub("o.user_id", builder.NewSQLBuilder().Table("a").Select("id")).
+		Where("o.user_id", ">", "w2").
+		GroupBy("o.user_id").
+		Having("COUNT(*)", ">", "h1").
+		UnionAll(archived).
+		OrderBy("ASC", "user_id").
+		Limit("l1", "l2")
+
+	for _, c := range []struct {
+		dialect builder.Dialect
+		sql     string
+		params  string
+	}{
+		{
+			builder.MySQL,
+			"WITH `a` AS (SELECT `id` FROM `users` WHERE `status` = ?) " +
+				"SELECT `o`.`user_id`,(SELECT COUNT(*) FROM `logins` AS `l` WHERE l.user_id = o.user_id AND `l`.`ok` = ?) AS `logins` " +
+				"FROM (SELECT `user_id` FROM `orders` WHERE `amount` > ?) AS `o` " +
+				"INNER JOIN (SELECT `id` FROM `shops` WHERE `open` = ?) AS `s` ON `s`.`id` = `o`.`user_id` AND `s`.`kind` = ? " +
+				"WHERE `o`.`user_id` IN (SELECT `id` FROM `a`) AND `o`.`user_id` > ? GROUP BY `o`.`user_id` HAVING COUNT(*) > ? " +
+				"UNION ALL (SELECT `user_id`,? FROM `archive` WHERE `year` = ? ORDER BY `year` DESC LIMIT ?,?) " +
+				"ORDER BY `user_id` ASC LIMIT ?,?",
+			"[w1 s1 f1 j1 j2 w2 h1 u1 u2 0 u3 l1 l2]",
+		},
+		{
+			builder.PostgreSQL,
+			`WITH "a" AS (SELECT "id" FROM "users" WHERE "status" = $1) ` +
+				`SELECT "o"."user_id",(SELECT COUNT(*) FROM "logins" AS "l" WHERE l.user_id = o.user_id AND "l"."ok" = $2) AS "logins" ` +
+				`FROM (SELECT "user_id" FROM "orders" WHERE "amount" > $3) AS "o" ` +
+				`INNER JOIN (SELECT "id" FROM "shops" WHERE "open" = $4) AS "s" ON "s"."id" = "o"."user_id" AND "s"."kind" = $5 ` +
+				`WHERE "o"."user_id" IN (SELECT "id" FROM "a") AND "o"."user_id" > $6 GROUP BY "o"."user_id" HAVING COUNT(*) > $7 ` +
+				`UNION ALL (SELECT "user_id",$8 FROM "archive" WHERE "year" = $9 ORDER BY "year" DESC LIMIT $10 OFFSET $11) ` +
+				`ORDER BY "user_id" ASC LIMIT $12 OFFSET $13`,
+			"[w1 s1 f1 j1 j2 w2 h1 u1 u2 u3 0 l2 l1]",
+		},
+	} {
+		sb.SetDialect(c.dialect)
+		sql, err := sb.GetQuerySql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != c.sql {
+			t.Errorf("%s:\n got %s\nwant %s", c.dialect.Name(), sql, c.sql)
+		}
+		if params := fmt.Sprint(sb.GetQueryParams()); params != c.params {
+			t.Errorf("%s: params %s, expected %s", c.dialect.Name(), params, c.params)
+		}
+	}
+}
+
+func TestSQLBuilder_Union(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		Table("admins").
+		Select("name").
+		Where("active", "=", 1).
+		Union(builder.NewSQLBuilder().Table("users").Select("name").Where("vip", "=", 1)).
+		UnionAll(builder.NewSQLBuilder().Table("guests").Select("name"))
+
+	sql, err := sb.SetDialect(builder.SQLite).GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT "name" FROM "admins" WHERE "active" = ? UNION SELECT "name" FROM "users" WHERE "vip" = ? UNION ALL SELECT "name" FROM "guests"`
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[1 1]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_WithRecursive(t *testing.T) {
+	build := func() *builder.SQLBuilder {
+		anchor := builder.NewSQLBuilder().Table("categories").Select("id", "parent_id").Where("id", "=", 1)
+		recursive := builder.NewSQLBuilder().
+			Table("categories c").
+			Select("c.id", "c.parent_id").
+			Join("tree t", func(c *builder.Cond) {
+				c.On("c.parent_id", "=", "t.id")
+			})
+
+		return builder.NewSQLBuilder().
+			WithRecursive("tree", anchor.UnionAll(recursive), "id", "parent_id").
+			With("top", builder.NewSQLBuilder().Table("tree").Select("id").Where("parent_id", "IS", nil)).
+			Table("tree").
+			WhereNotInSub("id", builder.NewSQLBuilder().Table("top").Select("id"))
+	}
+
+	sql, err := build().SetDialect(builder.PostgreSQL).GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `WITH RECURSIVE "tree" ("id","parent_id") AS (SELECT "id","parent_id" FROM "categories" WHERE "id" = $1 ` +
+		`UNION ALL SELECT "c"."id","c"."parent_id" FROM "categories" AS "c" INNER JOIN "tree" AS "t" ON "c"."parent_id" = "t"."id"), ` +
+		`"top" AS (SELECT "id" FROM "tree" WHERE "parent_id" IS $2) ` +
+		`SELECT * FROM "tree" WHERE "id" NOT IN (SELECT "id" FROM "top")`
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+
+	sql, _ = build().SetDialect(builder.SQLServer).GetQuerySql()
+	expected = `WITH [tree] ([id],[parent_id]) AS (SELECT [id],[parent_id] FROM [categories] WHERE [id] = @p1 ` +
+		`UNION ALL SELECT [c].[id],[c].[parent_id] FROM [categories] AS [c] INNER JOIN [tree] AS [t] ON [c].[parent_id] = [t].[id]), ` +
+		`[top] AS (SELECT [id] FROM [tree] WHERE [parent_id] IS @p2) ` +
+		`SELECT * FROM [tree] WHERE [id] NOT IN (SELECT [id] FROM [top])`
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+}
+
+func TestSQLBuilder_ComposeErrors(t *testing.T) {
+	_, err := builder.NewSQLBuilder().Table("a").Union(builder.NewSQLBuilder()).GetQuerySql()
+	if err != builder.ErrTableEmpty {
+		t.Errorf("expected ErrTableEmpty, got %v", err)
+	}
+
+	_, err = builder.NewSQLBuilder().With("x y", builder.NewSQLBuilder().Table("a")).Table("x").GetQuerySql()
+	if !errors.Is(err, builder.ErrUnsafeIdentifier) {
+		t.Errorf("expected ErrUnsafeIdentifier, got %v", err)
+	}
+
+	_, err = builder.NewSQLBuilder().FromSub(builder.NewSQLBuilder().Table("a"), "").GetQuerySql()
+	if !errors.Is(err, builder.ErrUnsafeIdentifier) {
+		t.Errorf("expected ErrUnsafeIdentifier, got %v", err)
+	}
+}
+
+// SQLite 不支持带括号的 UNION 成员，改为 SELECT * FROM (子查询)
+func TestSQLBuilder_UnionSQLite(t *testing.T) {
+	e := builder.NewExecutor(openDB(t))
+	seed(t, e)
+
+	youngest := builder.NewSQLBuilder().Table("users").Select("user_name").OrderBy("ASC", "user_age").Limit(0, 1)
+	sb := builder.NewSQLBuilder().SetDialect(builder.SQLite).
+		Table("users").
+		Select("user_name").
+		Where("user_age", ">", 30).
+		UnionAll(youngest)
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT "user_name" FROM "users" WHERE "user_age" > ? ` +
+		`UNION ALL SELECT * FROM (SELECT "user_name" FROM "users" ORDER BY "user_age" ASC LIMIT ? OFFSET ?)`
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+
+	var names []string
+	if err := e.Find(context.Background(), sb, &names); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(names) != "[carol bob]" {
+		t.Errorf("unexpected rows %v", names)
+	}
+}
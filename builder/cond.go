@@ -0,0 +1,96 @@
+package builder
+
+import "strings"
+
+// 一组用括号包裹的条件，在 WhereGroup 的回调中构造，可以继续嵌套
+type Cond struct {
+	sb     *SQLBuilder
+	exprs  []string
+	params []interface{}
+}
+
+func (c *Cond) add(operator string, expr string, values ...interface{}) *Cond {
+	if len(c.exprs) > 0 {
+		c.exprs = append(c.exprs, operator)
+	}
+	c.exprs = append(c.exprs, expr)
+	c.params = append(c.params, values...)
+	return c
+}
+
+func (c *Cond) Where(field, condition string, value interface{}) *Cond {
+	return c.add("AND", c.sb.compare(field, condition), value)
+}
+
+func (c *Cond) WhereOr(field, condition string, value interface{}) *Cond {
+	return c.add("OR", c.sb.compare(field, condition), value)
+}
+
+func (c *Cond) WhereRaw(s string, values []interface{}) *Cond {
+	return c.add("AND", s, values...)
+}
+
+func (c *Cond) WhereOrRaw(s string, values []interface{}) *Cond {
+	return c.add("OR", s, values...)
+}
+
+func (c *Cond) WhereIn(field string, values []interface{}) *Cond {
+	return c.add("AND", c.sb.in(field, "IN", len(values)), values...)
+}
+
+func (c *Cond) WhereNotIn(field string, values []interface{}) *Cond {
+	return c.add("AND", c.sb.in(field, "NOT IN", len(values)), values...)
+}
+
+func (c *Cond) WhereOrIn(field string, values []interface{}) *Cond {
+	return c.add("OR", c.sb.in(field, "IN", len(values)), values...)
+}
+
+func (c *Cond) WhereOrNotIn(field string, values []interface{}) *Cond {
+	return c.add("OR", c.sb.in(field, "NOT IN", len(values)), values...)
+}
+
+func (c *Cond) WhereGroup(fn func(c *Cond)) *Cond {
+	if expr, params := c.sb.group(fn); expr != "" {
+		c.add("AND", expr, params...)
+	}
+	return c
+}
+
+func (c *Cond) WhereOrGroup(fn func(c *Cond)) *Cond {
+	if expr, params := c.sb.group(fn); expr != "" {
+		c.add("OR", expr, params...)
+	}
+	return c
+}
+
+// a = ? AND (b = ? OR c = ?)，回调中没有添加条件时忽略
+func (sb *SQLBuilder) WhereGroup(fn func(c *Cond)) *SQLBuilder {
+	if expr, params := sb.group(fn); expr != "" {
+		sb.addWhere("AND", expr, params...)
+	}
+	return sb
+}
+
+func (sb *SQLBuilder) WhereOrGroup(fn func(c *Cond)) *SQLBuilder {
+	if expr, params := sb.group(fn); expr != "" {
+		sb.addWhere("OR", expr, params...)
+	}
+	return sb
+}
+
+func (sb *SQLBuilder) group(fn func(c *Cond)) (string, []interface{}) {
+	expr, params := sb.cond(fn)
+	if expr == "" {
+		return "", nil
+	}
+	return "(" + expr + ")", params
+}
+
+func (sb *SQLBuilder) cond(fn func(c *Cond)) (string, []interface{}) {
+	c := &Cond{sb: sb}
+	if fn != nil {
+		fn(c)
+	}
+	return strings.Join(c.exprs, " "), c.params
+}
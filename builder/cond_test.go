@@ -0,0 +1,75 @@
+package builder_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+func TestSQLBuilder_WhereGroup(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		Table("users").
+		Where("a", "=", 1).
+		WhereGroup(func(c *builder.Cond) {
+			c.Where("b", "=", 2).WhereOr("c", "=", 3)
+		}).
+		WhereOrGroup(func(c *builder.Cond) {
+			c.WhereIn("d", []interface{}{4, 5}).
+				WhereGroup(func(c *builder.Cond) {
+					c.WhereRaw("e > ?", []interface{}{6}).
+						WhereOrGroup(func(c *builder.Cond) {
+							c.Where("f", "<", 7).Where("g", "<>", 8)
+						})
+				})
+		}).
+		Where("h", "=", 9).
+		Limit(0, 10)
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT * FROM `users` WHERE `a` = ? AND (`b` = ? OR `c` = ?) OR (`d` IN (?,?) AND (e > ? OR (`f` < ? AND `g` <> ?))) AND `h` = ? LIMIT ?,?"
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[1 2 3 4 5 6 7 8 9 0 10]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_WhereGroupFirst(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		SetDialect(builder.PostgreSQL).
+		Table("users").
+		WhereGroup(func(c *builder.Cond) {}).
+		WhereOrGroup(func(c *builder.Cond) {
+			c.Where("a", "=", 1).WhereOr("b", "=", 2)
+		}).
+		Where("c", "=", 3)
+
+	sql, err := sb.GetDeleteSql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != `DELETE FROM "users" WHERE ("a" = $1 OR "b" = $2) AND "c" = $3` {
+		t.Errorf("unexpected %s", sql)
+	}
+	if fmt.Sprint(sb.GetDeleteParams()) != "[1 2 3]" {
+		t.Errorf("unexpected params %v", sb.GetDeleteParams())
+	}
+}
+
+func TestSQLBuilder_WhereGroupInvalid(t *testing.T) {
+	_, err := builder.NewSQLBuilder().
+		Table("users").
+		WhereGroup(func(c *builder.Cond) {
+			c.Where("a; --", "=", 1)
+		}).
+		GetQuerySql()
+	if !errors.Is(err, builder.ErrUnsafeIdentifier) {
+		t.Errorf("expected ErrUnsafeIdentifier, got %v", err)
+	}
+}
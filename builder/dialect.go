@@ -0,0 +1,137 @@
+package builder
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 不同数据库的占位符、标识符引用和分页语法
+type Dialect interface {
+	Name() string
+	//第 n 个参数的占位符，n 从 1 开始
+	Placeholder(n int) string
+	//引用单个标识符，如表名或列名
+	QuoteIdent(name string) string
+	//分页子句及其参数，hasOrderBy 表示语句中是否已有 ORDER BY
+	Limit(offset, num interface{}, hasOrderBy bool) (string, []interface{})
+}
+
+var (
+	MySQL      Dialect = mysqlDialect{}
+	PostgreSQL Dialect = postgresDialect{}
+	SQLite     Dialect = sqliteDialect{}
+	SQLServer  Dialect = sqlserverDialect{}
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (mysqlDialect) QuoteIdent(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+func (mysqlDialect) Limit(offset, num interface{}, hasOrderBy bool) (string, []interface{}) {
+	return "LIMIT ?,?", []interface{}{offset, num}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) QuoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (postgresDialect) Limit(offset, num interface{}, hasOrderBy bool) (string, []interface{}) {
+	return "LIMIT ? OFFSET ?", []interface{}{num, offset}
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Placeholder(n int) string {
+	return "?"
+}
+
+func (sqliteDialect) QuoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+func (sqliteDialect) Limit(offset, num interface{}, hasOrderBy bool) (string, []interface{}) {
+	return "LIMIT ? OFFSET ?", []interface{}{num, offset}
+}
+
+type sqlserverDialect struct{}
+
+func (sqlserverDialect) Name() string {
+	return "sqlserver"
+}
+
+func (sqlserverDialect) Placeholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+func (sqlserverDialect) QuoteIdent(name string) string {
+	return "[" + strings.Replace(name, "]", "]]", -1) + "]"
+}
+
+// OFFSET ... FETCH 必须跟在 ORDER BY 之后
+func (sqlserverDialect) Limit(offset, num interface{}, hasOrderBy bool) (string, []interface{}) {
+	clause := "OFFSET ? ROWS FETCH NEXT ? ROWS ONLY"
+	if !hasOrderBy {
+		clause = "ORDER BY (SELECT NULL) " + clause
+	}
+	return clause, []interface{}{offset, num}
+}
+
+// 将 ? 占位符替换为方言的占位符，跳过引号内的内容
+func rebind(d Dialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+
+	var buf strings.Builder
+	n := 0
+	var quote rune
+	for _, r := range query {
+		if quote != 0 {
+			buf.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch r {
+		case '\'', '"', '`':
+			quote = r
+		case '[':
+			if _, ok := d.(sqlserverDialect); ok {
+				quote = ']'
+			}
+		case '?':
+			n++
+			buf.WriteString(d.Placeholder(n))
+			continue
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+)
+
+// *sql.DB、*sql.Tx 和 *sql.Conn 都实现了该接口
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// 执行 SQLBuilder 生成的语句，SQLBuilder 的方言需要和数据库一致
+type Executor struct {
+	db Querier
+}
+
+func NewExecutor(db Querier) *Executor {
+	return &Executor{db: db}
+}
+
+// 查询所有行，dest 为结构体切片、结构体指针切片或单列值切片的指针
+func (e *Executor) Find(ctx context.Context, sb *SQLBuilder, dest interface{}) error {
+	query, err := sb.GetQuerySql()
+	if err != nil {
+		return err
+	}
+	rows, err := e.db.QueryContext(ctx, query, sb.GetQueryParams()...)
+	if err != nil {
+		return err
+	}
+	return ScanAll(rows, dest)
+}
+
+// 查询第一行，只取 1 条，保留 sb 已有的偏移量，不修改 sb，没有数据时返回 sql.ErrNoRows
+func (e *Executor) First(ctx context.Context, sb *SQLBuilder, dest interface{}) error {
+	first := *sb
+	if !first._hasLimit {
+		first._offset = 0
+	}
+	first.Limit(first._offset, 1)
+
+	query, err := first.GetQuerySql()
+	if err != nil {
+		return err
+	}
+	rows, err := e.db.QueryContext(ctx, query, first.GetQueryParams()...)
+	if err != nil {
+		return err
+	}
+	return ScanOne(rows, dest)
+}
+
+// SELECT COUNT(*) FROM (查询) AS t，分组和分页的查询也能得到正确的行数，
+// 排序不影响行数，SQL Server 也不允许子查询带 ORDER BY，生成时去掉
+func (e *Executor) Count(ctx context.Context, sb *SQLBuilder) (int64, error) {
+	sub := *sb
+	sub._orderBy = ""
+	count := NewSQLBuilder().SetDialect(sb.getDialect()).SelectRaw("COUNT(*)").FromSub(&sub, "t")
+
+	var n int64
+	if err := e.First(ctx, count, &n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// SELECT CASE WHEN EXISTS (查询) THEN 1 ELSE 0 END
+func (e *Executor) Exists(ctx context.Context, sb *SQLBuilder) (bool, error) {
+	//和 Count 一样去掉排序，SQL Server 的子查询中不允许单独使用 ORDER BY
+	sub := *sb
+	sub._orderBy = ""
+	sql, err := sub.querySql()
+	if err != nil {
+		return false, err
+	}
+	query := sub.render("SELECT CASE WHEN EXISTS (" + sql + ") THEN 1 ELSE 0 END")
+
+	rows, err := e.db.QueryContext(ctx, query, sub.GetQueryParams()...)
+	if err != nil {
+		return false, err
+	}
+	var n int
+	if err := ScanOne(rows, &n); err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// 执行任意语句，参数使用数据库驱动的占位符
+func (e *Executor) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return e.db.ExecContext(ctx, query, args...)
+}
+
+func (e *Executor) Insert(ctx context.Context, sb *SQLBuilder) (sql.Result, error) {
+	return e.exec(ctx, sb.GetInsertSql, sb.GetInsertParams)
+}
+
+func (e *Executor) InsertAll(ctx context.Context, sb *SQLBuilder) (sql.Result, error) {
+	return e.exec(ctx, sb.GetInsertAllSql, sb.GetInsertAllParams)
+}
+
+func (e *Executor) Update(ctx context.Context, sb *SQLBuilder) (sql.Result, error) {
+	return e.exec(ctx, sb.GetUpdateSql, sb.GetUpdateParams)
+}
+
+func (e *Executor) Delete(ctx context.Context, sb *SQLBuilder) (sql.Result, error) {
+	return e.exec(ctx, sb.GetDeleteSql, sb.GetDeleteParams)
+}
+
+func (e *Executor) exec(ctx context.Context, getSql func() (string, error), getParams func() []interface{}) (sql.Result, error) {
+	query, err := getSql()
+	if err != nil {
+		return nil, err
+	}
+	return e.db.ExecContext(ctx, query, getParams()...)
+}
@@ -0,0 +1,243 @@
+package builder_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/xuyang404/goutils/builder"
+)
+
+type execUser struct {
+	Id       int64  `gdb:"column:id"`
+	Name     string `gdb:"column:user_name"`
+	UserAge  int    `gdb:"underline"`
+	Nickname sql.NullString
+	Secret   string `gdb:"ignore"`
+}
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//内存数据库每个连接独立
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_name TEXT NOT NULL,
+		user_age INTEGER NOT NULL,
+		nickname TEXT,
+		secret TEXT
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func seed(t *testing.T, e *builder.Executor) {
+	sb := builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").
+		InsertAll([]string{"user_name", "user_age", "nickname", "secret"},
+			[]interface{}{"alice", 30, "al", "s1"},
+			[]interface{}{"bob", 25, nil, "s2"},
+			[]interface{}{"carol", 35, "cc", "s3"},
+		)
+	res, err := e.InsertAll(context.Background(), sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 3 {
+		t.Fatalf("inserted %d rows", n)
+	}
+}
+
+func TestExecutor_Find(t *testing.T) {
+	e := builder.NewExecutor(openDB(t))
+	seed(t, e)
+	ctx := context.Background()
+
+	var users []execUser
+	sb := builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Where("user_age", ">", 26).OrderBy("ASC", "id")
+	if err := e.Find(ctx, sb, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != "alice" || users[0].UserAge != 30 || users[1].Name != "carol" {
+		t.Fatalf("unexpected %+v", users)
+	}
+	if users[0].Nickname.String != "al" || users[0].Secret != "" || users[0].Id != 1 {
+		t.Errorf("unexpected %+v", users[0])
+	}
+
+	var ptrs []*execUser
+	if err := e.Find(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").WhereNull("nickname"), &ptrs); err != nil {
+		t.Fatal(err)
+	}
+	if len(ptrs) != 1 || ptrs[0].Name != "bob" || ptrs[0].Nickname.Valid {
+		t.Errorf("unexpected %+v", ptrs)
+	}
+
+	var names []string
+	if err := e.Find(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Select("user_name").OrderBy("DESC", "user_age"), &names); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[0] != "carol" || names[2] != "bob" {
+		t.Errorf("unexpected %v", names)
+	}
+
+	if err := e.Find(ctx, sb, users); err != builder.ErrDestination {
+		t.Errorf("expected ErrDestination, got %v", err)
+	}
+}
+
+func TestExecutor_First(t *testing.T) {
+	e := builder.NewExecutor(openDB(t))
+	seed(t, e)
+	ctx := context.Background()
+
+	var u execUser
+	if err := e.First(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").OrderBy("DESC", "user_age"), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "carol" {
+		t.Errorf("unexpected %+v", u)
+	}
+
+	err := e.First(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Where("id", "=", 99), &u)
+	if err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	//保留已有的偏移量，且不修改 sb
+	sb := builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").OrderBy("ASC", "id").Limit(1, 10)
+	if err := e.First(ctx, sb, &u); err != nil || u.Name != "bob" {
+		t.Errorf("unexpected %+v %v", u, err)
+	}
+	var list []execUser
+	if err := e.Find(ctx, sb, &list); err != nil || len(list) != 2 {
+		t.Errorf("unexpected %+v %v", list, err)
+	}
+
+	sb = builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users")
+	if err := e.First(ctx, sb, &u); err != nil {
+		t.Fatal(err)
+	}
+	list = nil
+	if err := e.Find(ctx, sb, &list); err != nil || len(list) != 3 {
+		t.Errorf("unexpected %+v %v", list, err)
+	}
+}
+
+// 只记录语句的 Querier
+type recordQuerier struct {
+	queries []string
+}
+
+func (q *recordQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	q.queries = append(q.queries, query)
+	return nil, context.Canceled
+}
+
+func (q *recordQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	q.queries = append(q.queries, query)
+	return nil, context.Canceled
+}
+
+func TestExecutor_CountExistsOrderBy(t *testing.T) {
+	q := &recordQuerier{}
+	e := builder.NewExecutor(q)
+
+	sb := builder.NewSQLBuilder().SetDialect(builder.SQLServer).Table("users").Where("user_age", ">", 1).OrderBy("DESC", "id")
+	e.Count(context.Background(), sb)
+	if len(q.queries) != 1 || q.queries[0] != "SELECT COUNT(*) FROM (SELECT * FROM [users] WHERE [user_age] > @p1) AS [t] ORDER BY (SELECT NULL) OFFSET @p2 ROWS FETCH NEXT @p3 ROWS ONLY" {
+		t.Errorf("unexpected %q", q.queries)
+	}
+
+	e.Exists(context.Background(), sb)
+	if len(q.queries) != 2 || q.queries[1] != "SELECT CASE WHEN EXISTS (SELECT * FROM [users] WHERE [user_age] > @p1) THEN 1 ELSE 0 END" {
+		t.Errorf("unexpected %q", q.queries)
+	}
+
+	//原查询的排序不受影响
+	if sql, _ := sb.GetQuerySql(); sql != "SELECT * FROM [users] WHERE [user_age] > @p1 ORDER BY [id] DESC" {
+		t.Errorf("unexpected %s", sql)
+	}
+}
+
+func TestExecutor_CountExists(t *testing.T) {
+	e := builder.NewExecutor(openDB(t))
+	seed(t, e)
+	ctx := context.Background()
+
+	n, err := e.Count(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Where("user_age", ">=", 30))
+	if err != nil || n != 2 {
+		t.Errorf("Count = %d, %v", n, err)
+	}
+
+	//分页后的行数
+	n, err = e.Count(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Limit(1, 10))
+	if err != nil || n != 2 {
+		t.Errorf("Count = %d, %v", n, err)
+	}
+
+	ok, err := e.Exists(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Where("user_name", "=", "bob"))
+	if err != nil || !ok {
+		t.Errorf("Exists = %v, %v", ok, err)
+	}
+	ok, err = e.Exists(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Where("user_name", "=", "dave"))
+	if err != nil || ok {
+		t.Errorf("Exists = %v, %v", ok, err)
+	}
+}
+
+func TestExecutor_Exec(t *testing.T) {
+	db := openDB(t)
+	e := builder.NewExecutor(db)
+	seed(t, e)
+	ctx := context.Background()
+
+	res, err := e.Update(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").
+		Update([]string{"user_age"}, 40).Where("user_name", "=", "bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("updated %d rows", n)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	te := builder.NewExecutor(tx)
+	if _, err := te.Delete(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Where("user_age", "=", 40)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := te.Insert(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").
+		Insert([]string{"user_name", "user_age"}, "dave", 20)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := te.Exec(ctx, "UPDATE users SET nickname = ? WHERE user_name = ?", "d", "dave"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	if err := e.Find(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Select("user_name").OrderBy("ASC", "id"), &names); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[1] != "carol" || names[2] != "dave" {
+		t.Errorf("unexpected %v", names)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := e.Count(ctx, builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users")); err == nil {
+		t.Error("expected context error")
+	}
+}
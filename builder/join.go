@@ -0,0 +1,71 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrJoinCondition = errors.New("join requires an ON condition")
+
+// ON 中比较两列，如 c.On("t1.id", "=", "t2.user_id")，和值比较时使用 Where
+func (c *Cond) On(first, condition, second string) *Cond {
+	return c.add("AND", c.sb.ident(first)+" "+c.sb.operator(condition)+" "+c.sb.ident(second))
+}
+
+func (c *Cond) OrOn(first, condition, second string) *Cond {
+	return c.add("OR", c.sb.ident(first)+" "+c.sb.operator(condition)+" "+c.sb.ident(second))
+}
+
+// INNER JOIN table ON ...，table 支持别名，如 "orders o"
+func (sb *SQLBuilder) Join(table string, on func(c *Cond)) *SQLBuilder {
+	return sb.join("INNER JOIN", sb.ident(table), nil, on)
+}
+
+func (sb *SQLBuilder) LeftJoin(table string, on func(c *Cond)) *SQLBuilder {
+	return sb.join("LEFT JOIN", sb.ident(table), nil, on)
+}
+
+func (sb *SQLBuilder) RightJoin(table string, on func(c *Cond)) *SQLBuilder {
+	return sb.join("RIGHT JOIN", sb.ident(table), nil, on)
+}
+
+// CROSS JOIN 不带 ON 条件
+func (sb *SQLBuilder) CrossJoin(table string) *SQLBuilder {
+	return sb.JoinRaw("CROSS JOIN " + sb.ident(table))
+}
+
+// INNER JOIN (子查询) AS alias ON ...
+func (sb *SQLBuilder) JoinSub(sub *SQLBuilder, alias string, on func(c *Cond)) *SQLBuilder {
+	source, params := sb.subAs(sub, alias)
+	return sb.join("INNER JOIN", source, params, on)
+}
+
+func (sb *SQLBuilder) LeftJoinSub(sub *SQLBuilder, alias string, on func(c *Cond)) *SQLBuilder {
+	source, params := sb.subAs(sub, alias)
+	return sb.join("LEFT JOIN", source, params, on)
+}
+
+func (sb *SQLBuilder) RightJoinSub(sub *SQLBuilder, alias string, on func(c *Cond)) *SQLBuilder {
+	source, params := sb.subAs(sub, alias)
+	return sb.join("RIGHT JOIN", source, params, on)
+}
+
+func (sb *SQLBuilder) join(kind string, source string, params []interface{}, on func(c *Cond)) *SQLBuilder {
+	expr, onParams := sb.cond(on)
+	if expr == "" {
+		sb.setErr(fmt.Errorf("%w: %s", ErrJoinCondition, kind))
+		return sb
+	}
+	return sb.JoinRaw(kind+" "+source+" ON "+expr, append(params, onParams...)...)
+}
+
+// (子查询) AS alias，子查询必须有别名
+func (sb *SQLBuilder) subAs(sub *SQLBuilder, alias string) (string, []interface{}) {
+	sql, params := sb.sub(sub)
+	a, err := markPart(alias)
+	if err != nil {
+		sb.setErr(fmt.Errorf("%w: %q", ErrUnsafeIdentifier, alias))
+		return "", nil
+	}
+	return sql + " AS " + a, params
+}
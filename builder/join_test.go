@@ -0,0 +1,110 @@
+package builder_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+func TestSQLBuilder_Join(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		Table("users u").
+		Select("u.name", "o.amount", "c.title").
+		Join("orders o", func(c *builder.Cond) {
+			c.On("o.user_id", "=", "u.id").Where("o.status", "=", "paid")
+		}).
+		LeftJoin("coupons AS c", func(c *builder.Cond) {
+			c.On("c.order_id", "=", "o.id").
+				WhereGroup(func(c *builder.Cond) {
+					c.Where("c.kind", "=", "vip").OrOn("c.user_id", "=", "u.id")
+				})
+		}).
+		RightJoin("shops s", func(c *builder.Cond) {
+			c.On("s.id", "=", "o.shop_id").WhereOr("s.open", "=", true)
+		}).
+		CrossJoin("regions").
+		Where("u.age", ">", 18).
+		Limit(0, 10)
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT `u`.`name`,`o`.`amount`,`c`.`title` FROM `users` AS `u` " +
+		"INNER JOIN `orders` AS `o` ON `o`.`user_id` = `u`.`id` AND `o`.`status` = ? " +
+		"LEFT JOIN `coupons` AS `c` ON `c`.`order_id` = `o`.`id` AND (`c`.`kind` = ? OR `c`.`user_id` = `u`.`id`) " +
+		"RIGHT JOIN `shops` AS `s` ON `s`.`id` = `o`.`shop_id` OR `s`.`open` = ? " +
+		"CROSS JOIN `regions` WHERE `u`.`age` > ? LIMIT ?,?"
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[paid vip true 18 0 10]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_JoinSub(t *testing.T) {
+	totals := builder.NewSQLBuilder().
+		Table("orders").
+		Select("user_id").
+		SelectRaw("SUM(amount) AS total").
+		Where("status", "=", "paid").
+		GroupBy("user_id").
+		Having("SUM(amount)", ">", 100)
+
+	sb := builder.NewSQLBuilder().
+		SetDialect(builder.PostgreSQL).
+		Table("users u").
+		Select("u.name", "t.total").
+		SelectRaw("? AS tag", "top").
+		JoinSub(totals, "t", func(c *builder.Cond) {
+			c.On("t.user_id", "=", "u.id").Where("t.total", "<", 1000)
+		}).
+		LeftJoinSub(builder.NewSQLBuilder().Table("bans").Select("user_id").Where("active", "=", 1), "b", func(c *builder.Cond) {
+			c.On("b.user_id", "=", "u.id")
+		}).
+		WhereNull("b.user_id")
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT "u"."name","t"."total",$1 AS tag FROM "users" AS "u" ` +
+		`INNER JOIN (SELECT "user_id",SUM(amount) AS total FROM "orders" WHERE "status" = $2 GROUP BY "user_id" HAVING SUM("amount") > $3) AS "t" ` +
+		`ON "t"."user_id" = "u"."id" AND "t"."total" < $4 ` +
+		`LEFT JOIN (SELECT "user_id" FROM "bans" WHERE "active" = $5) AS "b" ON "b"."user_id" = "u"."id" ` +
+		`WHERE "b"."user_id" IS NULL`
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[top paid 100 1000 1]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_JoinErrors(t *testing.T) {
+	_, err := builder.NewSQLBuilder().Table("users").Join("orders", nil).GetQuerySql()
+	if !errors.Is(err, builder.ErrJoinCondition) {
+		t.Errorf("expected ErrJoinCondition, got %v", err)
+	}
+
+	_, err = builder.NewSQLBuilder().Table("users").
+		JoinSub(builder.NewSQLBuilder().Table("orders"), "o; --", func(c *builder.Cond) {
+			c.On("o.user_id", "=", "users.id")
+		}).
+		GetQuerySql()
+	if !errors.Is(err, builder.ErrUnsafeIdentifier) {
+		t.Errorf("expected ErrUnsafeIdentifier, got %v", err)
+	}
+
+	_, err = builder.NewSQLBuilder().Table("users").
+		Join("orders", func(c *builder.Cond) {
+			c.On("orders.user_id", "= users.id OR 1 =", "1")
+		}).
+		GetQuerySql()
+	if !errors.Is(err, builder.ErrOperator) {
+		t.Errorf("expected ErrOperator, got %v", err)
+	}
+}
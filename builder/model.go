@@ -0,0 +1,175 @@
+package builder
+
+import (
+	"errors"
+	"reflect"
+)
+
+var ErrPrimaryKey = errors.New("primary key is empty")
+
+type modelOptions struct {
+	nonZero  bool
+	original reflect.Value
+}
+
+// UpdateModel 的选项
+type ModelOption func(o *modelOptions)
+
+// 只更新非零值字段，带 require 标签的字段始终更新
+func NonZero() ModelOption {
+	return func(o *modelOptions) {
+		o.nonZero = true
+	}
+}
+
+// 只更新和 original 不同的字段，original 为修改前的同类型结构体或其指针
+func ChangedFrom(original interface{}) ModelOption {
+	return func(o *modelOptions) {
+		o.original = reflect.Indirect(reflect.ValueOf(original))
+	}
+}
+
+type modelField struct {
+	index   []int
+	column  string
+	value   reflect.Value
+	primary bool
+	require bool
+	skip    bool
+}
+
+// 字段和 InsertModel 一样由 reflectElementInfo 的规则解析，skip 为其中跳过的字段，
+// 带 primary 标签的字段为主键，没有时使用 Id 字段
+func modelFields(model interface{}) ([]modelField, error) {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	if v.Kind() != reflect.Struct {
+		return nil, ErrElementStatement
+	}
+
+	list := getStructInfo(v.Type()).list
+	fields := make([]modelField, 0, len(list))
+	hasPrimary := false
+	for _, f := range list {
+		field := modelField{
+			index:   f.index,
+			column:  f.column,
+			value:   fieldValue(v, f.index),
+			primary: f.tag.primary,
+			require: f.tag.require,
+			skip:    f.tag.skip(f.name),
+		}
+		hasPrimary = hasPrimary || field.primary
+		fields = append(fields, field)
+	}
+
+	if !hasPrimary {
+		for i, f := range list {
+			if f.name == ignoreKey {
+				fields[i].primary = true
+				break
+			}
+		}
+	}
+	return fields, nil
+}
+
+func primaryKey(fields []modelField) (modelField, error) {
+	for _, f := range fields {
+		if f.primary {
+			if f.value.IsZero() {
+				return f, ErrPrimaryKey
+			}
+			return f, nil
+		}
+	}
+	return modelField{}, ErrPrimaryKey
+}
+
+// 插入单个结构体，规则同 InsertAllModel，可以是结构体或其指针
+func (sb *SQLBuilder) InsertModel(model interface{}) (string, error) {
+	fields, values, err := sb.reflectElementInfo(model)
+	if err != nil {
+		return "", err
+	}
+	if len(values) != 1 {
+		return "", ErrElementStatement
+	}
+
+	return sb.Insert(fields, values[0]...).GetInsertSql()
+}
+
+// 按主键更新结构体，主键不参与 SET，默认更新所有字段
+func (sb *SQLBuilder) UpdateModel(model interface{}, opts ...ModelOption) (string, error) {
+	o := &modelOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	fields, err := modelFields(model)
+	if err != nil {
+		return "", err
+	}
+	pk, err := primaryKey(fields)
+	if err != nil {
+		return "", err
+	}
+	if o.original.IsValid() && o.original.Type() != reflect.Indirect(reflect.ValueOf(model)).Type() {
+		return "", ErrElementStatement
+	}
+
+	columns := make([]string, 0, len(fields))
+	values := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		if f.primary || f.skip {
+			continue
+		}
+		if !f.require {
+			if o.nonZero && f.value.IsZero() {
+				continue
+			}
+			if o.original.IsValid() && reflect.DeepEqual(f.value.Interface(), fieldValue(o.original, f.index).Interface()) {
+				continue
+			}
+		}
+		columns = append(columns, f.column)
+		values = append(values, f.value.Interface())
+	}
+	if len(columns) == 0 {
+		return "", ErrUpdateStatement
+	}
+
+	return sb.Update(columns, values...).Where(pk.column, "=", pk.value.Interface()).GetUpdateSql()
+}
+
+// 插入结构体，主键冲突时更新其余字段，主键为零值时不写入主键
+func (sb *SQLBuilder) UpsertModel(model interface{}) (string, error) {
+	fields, err := modelFields(model)
+	if err != nil {
+		return "", err
+	}
+
+	var pk *modelField
+	columns := make([]string, 0, len(fields))
+	values := make([]interface{}, 0, len(fields))
+	update := make([]string, 0, len(fields))
+	for i, f := range fields {
+		if f.primary {
+			pk = &fields[i]
+			if f.value.IsZero() && !f.require {
+				continue
+			}
+		} else {
+			if f.skip {
+				continue
+			}
+			update = append(update, f.column)
+		}
+		columns = append(columns, f.column)
+		values = append(values, f.value.Interface())
+	}
+	if pk == nil {
+		return "", ErrPrimaryKey
+	}
+
+	return sb.Insert(columns, values...).upsert([]string{pk.column}, update).GetInsertSql()
+}
@@ -0,0 +1,261 @@
+package builder_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+type product struct {
+	Id      int64  `gdb:"column:id"`
+	Name    string `gdb:"column:name"`
+	Price   int    `gdb:"column:price"`
+	Stock   int    `gdb:"column:stock;require"`
+	Cache   string `gdb:"ignore"`
+	UserAge int    `gdb:"underline"`
+}
+
+type sku struct {
+	Code  string `gdb:"column:code;primary"`
+	Title string `gdb:"column:title"`
+	Id    int64  `gdb:"column:id"`
+}
+
+func TestSQLBuilder_InsertModel(t *testing.T) {
+	sb := builder.NewSQLBuilder().Table("products")
+	sql, err := sb.InsertModel(&product{Id: 9, Name: "pen", Price: 3, Cache: "x", UserAge: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "INSERT INTO `products` (`name`,`price`,`stock`,`user_age`) VALUES (?,?,?,?)" {
+		t.Errorf("unexpected %s", sql)
+	}
+	if fmt.Sprint(sb.GetInsertParams()) != "[pen 3 0 1]" {
+		t.Errorf("unexpected params %v", sb.GetInsertParams())
+	}
+
+	if _, err := builder.NewSQLBuilder().Table("products").InsertModel([]product{{}, {}}); err != builder.ErrElementStatement {
+		t.Errorf("expected ErrElementStatement, got %v", err)
+	}
+}
+
+func TestSQLBuilder_UpdateModel(t *testing.T) {
+	p := product{Id: 7, Name: "pen", Price: 0, Stock: 0, UserAge: 2}
+
+	sb := builder.NewSQLBuilder().Table("products")
+	sql, err := sb.UpdateModel(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "UPDATE `products` SET `name` = ?,`price` = ?,`stock` = ?,`user_age` = ? WHERE `id` = ?" {
+		t.Errorf("unexpected %s", sql)
+	}
+	if fmt.Sprint(sb.GetUpdateParams()) != "[pen 0 0 2 7]" {
+		t.Errorf("unexpected params %v", sb.GetUpdateParams())
+	}
+
+	//require 的字段始终更新
+	sb = builder.NewSQLBuilder().Table("products")
+	sql, _ = sb.UpdateModel(&p, builder.NonZero())
+	if sql != "UPDATE `products` SET `name` = ?,`stock` = ?,`user_age` = ? WHERE `id` = ?" {
+		t.Errorf("unexpected %s", sql)
+	}
+
+	original := p
+	p.Price = 5
+	p.UserAge = 3
+	sb = builder.NewSQLBuilder().SetDialect(builder.PostgreSQL).Table("products")
+	sql, _ = sb.UpdateModel(&p, builder.ChangedFrom(&original))
+	if sql != `UPDATE "products" SET "price" = $1,"stock" = $2,"user_age" = $3 WHERE "id" = $4` {
+		t.Errorf("unexpected %s", sql)
+	}
+	if fmt.Sprint(sb.GetUpdateParams()) != "[5 0 3 7]" {
+		t.Errorf("unexpected params %v", sb.GetUpdateParams())
+	}
+
+	//另有主键时 Id 和 InsertModel 一样默认跳过
+	sb = builder.NewSQLBuilder().Table("skus")
+	sql, _ = sb.UpdateModel(sku{Code: "A1", Title: "t", Id: 3})
+	if sql != "UPDATE `skus` SET `title` = ? WHERE `code` = ?" {
+		t.Errorf("unexpected %s", sql)
+	}
+
+	if _, err := builder.NewSQLBuilder().Table("products").UpdateModel(product{Name: "x"}); err != builder.ErrPrimaryKey {
+		t.Errorf("expected ErrPrimaryKey, got %v", err)
+	}
+	if _, err := builder.NewSQLBuilder().Table("products").UpdateModel(p, builder.ChangedFrom(sku{})); err != builder.ErrElementStatement {
+		t.Errorf("expected ErrElementStatement, got %v", err)
+	}
+	if _, err := builder.NewSQLBuilder().Table("skus").UpdateModel(sku{Code: "A1"}, builder.NonZero()); err != builder.ErrUpdateStatement {
+		t.Errorf("expected ErrUpdateStatement, got %v", err)
+	}
+}
+
+func TestSQLBuilder_ModelTag(t *testing.T) {
+	//选项按 ; 分隔后完全匹配，列名中含有 primary、ignore 不算标签
+	type note struct {
+		Id      int64  `gdb:"column:id"`
+		I       int    `gdb:"column:i"`
+		D       int    `gdb:"column:d"`
+		Ignored string `gdb:"column:ignored_at"`
+		Primary string `gdb:"column:primary_tag"`
+	}
+	n := note{Id: 1, I: 2, D: 3, Ignored: "x", Primary: "y"}
+
+	sb := builder.NewSQLBuilder().Table("notes")
+	sql, err := sb.InsertModel(n)
+	if err != nil || sql != "INSERT INTO `notes` (`i`,`d`,`ignored_at`,`primary_tag`) VALUES (?,?,?,?)" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+
+	sb = builder.NewSQLBuilder().Table("notes")
+	sql, err = sb.UpdateModel(n)
+	if err != nil || sql != "UPDATE `notes` SET `i` = ?,`d` = ?,`ignored_at` = ?,`primary_tag` = ? WHERE `id` = ?" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+
+	sb = builder.NewSQLBuilder().Table("notes")
+	sql, err = sb.UpsertModel(n)
+	if err != nil || sql != "INSERT INTO `notes` (`id`,`i`,`d`,`ignored_at`,`primary_tag`) VALUES (?,?,?,?,?) "+
+		"ON DUPLICATE KEY UPDATE `i` = VALUES(`i`),`d` = VALUES(`d`),`ignored_at` = VALUES(`ignored_at`),`primary_tag` = VALUES(`primary_tag`)" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+}
+
+// 嵌入结构体的字段和 ScanAll 一样展开，外层同名字段优先
+func TestSQLBuilder_ModelEmbedded(t *testing.T) {
+	type base struct {
+		Id      int64  `gdb:"column:id"`
+		Created string `gdb:"column:created"`
+		Name    string `gdb:"column:name"`
+	}
+	type user struct {
+		base
+		Name string `gdb:"column:name"`
+		Age  int    `gdb:"column:age"`
+	}
+	u := user{base: base{Id: 4, Created: "today", Name: "inner"}, Name: "outer", Age: 20}
+
+	sb := builder.NewSQLBuilder().Table("users")
+	sql, err := sb.InsertModel(u)
+	if err != nil || sql != "INSERT INTO `users` (`created`,`name`,`age`) VALUES (?,?,?)" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+	if fmt.Sprint(sb.GetInsertParams()) != "[today outer 20]" {
+		t.Errorf("unexpected params %v", sb.GetInsertParams())
+	}
+
+	sb = builder.NewSQLBuilder().Table("users")
+	sql, err = sb.UpdateModel(&u)
+	if err != nil || sql != "UPDATE `users` SET `created` = ?,`name` = ?,`age` = ? WHERE `id` = ?" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+	if fmt.Sprint(sb.GetUpdateParams()) != "[today outer 20 4]" {
+		t.Errorf("unexpected params %v", sb.GetUpdateParams())
+	}
+
+	original := u
+	u.Created = "tomorrow"
+	sb = builder.NewSQLBuilder().Table("users")
+	sql, err = sb.UpdateModel(u, builder.ChangedFrom(original))
+	if err != nil || sql != "UPDATE `users` SET `created` = ? WHERE `id` = ?" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+
+	sb = builder.NewSQLBuilder().Table("users")
+	sql, err = sb.InsertAllModel([]*user{&u, {Name: "b"}})
+	if err != nil || sql != "INSERT INTO `users` (`created`,`name`,`age`) VALUES (?,?,?),(?,?,?)" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+}
+
+func TestSQLBuilder_UpsertModel(t *testing.T) {
+	p := &product{Id: 7, Name: "pen", Price: 3, Stock: 1}
+
+	for _, c := range []struct {
+		dialect builder.Dialect
+		sql     string
+	}{
+		{builder.MySQL, "INSERT INTO `products` (`id`,`name`,`price`,`stock`,`user_age`) VALUES (?,?,?,?,?) " +
+			"ON DUPLICATE KEY UPDATE `name` = VALUES(`name`),`price` = VALUES(`price`),`stock` = VALUES(`stock`),`user_age` = VALUES(`user_age`)"},
+		{builder.PostgreSQL, `INSERT INTO "products" ("id","name","price","stock","user_age") VALUES ($1,$2,$3,$4,$5) ` +
+			`ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name","price" = EXCLUDED."price","stock" = EXCLUDED."stock","user_age" = EXCLUDED."user_age"`},
+	} {
+		sb := builder.NewSQLBuilder().Table("products").SetDialect(c.dialect)
+		sql, err := sb.UpsertModel(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != c.sql {
+			t.Errorf("%s:\n got %s\nwant %s", c.dialect.Name(), sql, c.sql)
+		}
+		if fmt.Sprint(sb.GetInsertParams()) != "[7 pen 3 1 0]" {
+			t.Errorf("unexpected params %v", sb.GetInsertParams())
+		}
+	}
+
+	//另有主键时 Id 不写入也不更新
+	sb := builder.NewSQLBuilder().Table("skus")
+	sql, err := sb.UpsertModel(sku{Code: "A1", Title: "t", Id: 3})
+	if err != nil || sql != "INSERT INTO `skus` (`code`,`title`) VALUES (?,?) ON DUPLICATE KEY UPDATE `title` = VALUES(`title`)" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+
+	if _, err := builder.NewSQLBuilder().Table("products").SetDialect(builder.SQLServer).UpsertModel(p); err != builder.ErrUpsertUnsupported {
+		t.Errorf("expected ErrUpsertUnsupported, got %v", err)
+	}
+}
+
+func TestSQLBuilder_ModelSQLite(t *testing.T) {
+	db := openDB(t)
+	e := builder.NewExecutor(db)
+	ctx := context.Background()
+
+	type row struct {
+		Id       int64  `gdb:"column:id"`
+		UserName string `gdb:"underline"`
+		UserAge  int    `gdb:"underline"`
+	}
+
+	newSB := func() *builder.SQLBuilder {
+		return builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users")
+	}
+	run := func(sb *builder.SQLBuilder, sql string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.Exec(ctx, sql, sb.GetInsertParams()...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sb := newSB()
+	sql, err := sb.UpsertModel(row{UserName: "alice", UserAge: 30})
+	run(sb, sql, err)
+	sb = newSB()
+	sql, err = sb.UpsertModel(row{Id: 1, UserName: "alice", UserAge: 31})
+	run(sb, sql, err)
+	sb = newSB()
+	sql, err = sb.UpsertModel(row{Id: 5, UserName: "bob", UserAge: 20})
+	run(sb, sql, err)
+
+	sb = newSB()
+	sql, err = sb.UpdateModel(row{Id: 5, UserAge: 21}, builder.NonZero())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Exec(ctx, sql, sb.GetUpdateParams()...); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []row
+	if err := e.Find(ctx, newSB().Select("id", "user_name", "user_age").OrderBy("ASC", "id"), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(rows) != "[{1 alice 31} {5 bob 21}]" {
+		t.Errorf("unexpected %v", rows)
+	}
+}
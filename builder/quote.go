@@ -0,0 +1,270 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 标识符先用标记包裹，生成 SQL 时再按方言引用，这样 SetDialect 的调用顺序不影响结果
+const (
+	identOpen  = '\x01'
+	identClose = '\x02'
+)
+
+var (
+	ErrUnsafeIdentifier = errors.New("unsafe identifier")
+	ErrOrderDirection   = errors.New("order direction must be ASC or DESC")
+	ErrOperator         = errors.New("unsupported operator")
+
+	safeIdent = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_$]*$`)
+	aggregate = regexp.MustCompile(`(?i)^(COUNT|SUM|AVG|MIN|MAX)\s*\(\s*(DISTINCT\s+)?(.*?)\s*\)$`)
+
+	operators = map[string]bool{
+		"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true, "<=>": true,
+		"LIKE": true, "NOT LIKE": true, "ILIKE": true, "NOT ILIKE": true, "REGEXP": true, "NOT REGEXP": true,
+		"IS": true, "IS NOT": true,
+	}
+)
+
+// 记录第一个错误，在 Get*Sql 时返回，保持链式调用
+func (sb *SQLBuilder) setErr(err error) {
+	if sb.err == nil {
+		sb.err = err
+	}
+}
+
+func (sb *SQLBuilder) Err() error {
+	return sb.err
+}
+
+// 解析并标记标识符，支持 name、t1.name、t1.*、`t1`.`name` 以及 name AS alias、name alias
+func (sb *SQLBuilder) ident(s string) string {
+	q, err := parseIdent(s)
+	if err != nil {
+		sb.setErr(err)
+		return ""
+	}
+	return q
+}
+
+// HAVING 的字段，标识符或 COUNT(*)、SUM(t1.amount)、COUNT(DISTINCT id) 这类聚合函数
+func (sb *SQLBuilder) aggregateIdent(s string) string {
+	m := aggregate.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return sb.ident(s)
+	}
+
+	fn := strings.ToUpper(m[1]) + "("
+	if m[2] != "" {
+		fn += "DISTINCT "
+	}
+	if m[3] == "*" && m[2] == "" && fn == "COUNT(" {
+		return fn + "*)"
+	}
+	q, err := parseIdent(m[3])
+	if err != nil || m[3] == "*" || strings.Contains(q, " AS ") {
+		sb.setErr(fmt.Errorf("%w: %q", ErrUnsafeIdentifier, s))
+		return ""
+	}
+	return fn + q + ")"
+}
+
+func (sb *SQLBuilder) idents(list []string) []string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = sb.ident(s)
+	}
+	return out
+}
+
+func (sb *SQLBuilder) operator(condition string) string {
+	op := strings.ToUpper(strings.Join(strings.Fields(condition), " "))
+	if !operators[op] {
+		sb.setErr(fmt.Errorf("%w: %q", ErrOperator, condition))
+		return ""
+	}
+	return op
+}
+
+func parseIdent(s string) (string, error) {
+	tokens, err := splitOutside(strings.TrimSpace(s), func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' })
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeIdentifier, s)
+	}
+
+	var name, alias string
+	switch {
+	case len(tokens) == 1:
+		name = tokens[0]
+	case len(tokens) == 2:
+		name, alias = tokens[0], tokens[1]
+	case len(tokens) == 3 && strings.EqualFold(tokens[1], "AS"):
+		name, alias = tokens[0], tokens[2]
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsafeIdentifier, s)
+	}
+
+	parts, err := splitOutside(name, func(r rune) bool { return r == '.' })
+	if err != nil || len(parts) == 0 {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeIdentifier, s)
+	}
+
+	marked := make([]string, len(parts))
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 && alias == "" {
+			marked[i] = "*"
+			continue
+		}
+		if marked[i], err = markPart(part); err != nil {
+			return "", fmt.Errorf("%w: %q", ErrUnsafeIdentifier, s)
+		}
+	}
+
+	q := strings.Join(marked, ".")
+	if alias != "" {
+		a, err := markPart(alias)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q", ErrUnsafeIdentifier, s)
+		}
+		q += " AS " + a
+	}
+	return q, nil
+}
+
+// 已引用的部分去掉引号后按方言重新转义，未引用的部分只允许字母、数字、下划线
+func markPart(part string) (string, error) {
+	name := part
+	if len(part) >= 2 {
+		switch {
+		case part[0] == '`' && part[len(part)-1] == '`':
+			name = strings.Replace(part[1:len(part)-1], "``", "`", -1)
+		case part[0] == '"' && part[len(part)-1] == '"':
+			name = strings.Replace(part[1:len(part)-1], `""`, `"`, -1)
+		case part[0] == '[' && part[len(part)-1] == ']':
+			name = strings.Replace(part[1:len(part)-1], "]]", "]", -1)
+		}
+	}
+
+	if name == part && !safeIdent.MatchString(name) {
+		return "", ErrUnsafeIdentifier
+	}
+	if name == "" || strings.ContainsAny(name, string([]rune{identOpen, identClose, limitMark, limitOrderByMark, recursiveMark, unionMark, 0})) {
+		return "", ErrUnsafeIdentifier
+	}
+	return string(identOpen) + name + string(identClose), nil
+}
+
+// 按分隔符拆分，忽略引号内的分隔符
+func splitOutside(s string, sep func(rune) bool) ([]string, error) {
+	parts := make([]string, 0)
+	var cur strings.Builder
+	var quote rune
+	for _, r := range s {
+		if quote != 0 {
+			cur.WriteRune(r)
+			if r == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case r == '`' || r == '"':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case sep(r):
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if quote != 0 {
+		return nil, ErrUnsafeIdentifier
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts, nil
+}
+
+// 分页子句的语法和参数顺序都取决于方言，先用标记占位，嵌套在子查询中也能按最终方言生成
+const (
+	limitMark        = '\x03'
+	limitOrderByMark = '\x04'
+	//SQL Server 的递归 CTE 不需要 RECURSIVE 关键字
+	recursiveMark = '\x05'
+	//带排序或分页的 UNION 成员加括号，SQLite 不支持括号，改为 SELECT * FROM (子查询)
+	unionMark = '\x06'
+)
+
+type limitArgs struct {
+	offset, num interface{}
+	hasOrderBy  bool
+}
+
+func (a limitArgs) mark() string {
+	if a.hasOrderBy {
+		return string(limitOrderByMark)
+	}
+	return string(limitMark)
+}
+
+// 展开参数中的分页参数
+func (sb *SQLBuilder) args(params []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(params))
+	for _, p := range params {
+		if a, ok := p.(limitArgs); ok {
+			_, limitParams := sb.getDialect().Limit(a.offset, a.num, a.hasOrderBy)
+			out = append(out, limitParams...)
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// 替换标识符和分页标记并重写占位符
+func (sb *SQLBuilder) render(sql string) string {
+	d := sb.getDialect()
+
+	var buf strings.Builder
+	for {
+		i := strings.IndexAny(sql, string([]rune{identOpen, limitMark, limitOrderByMark, recursiveMark, unionMark}))
+		if i < 0 {
+			break
+		}
+		buf.WriteString(sql[:i])
+
+		switch rune(sql[i]) {
+		case identOpen:
+			j := strings.IndexRune(sql[i:], identClose) + i
+			buf.WriteString(d.QuoteIdent(sql[i+1 : j]))
+			sql = sql[j+1:]
+			continue
+		case limitMark:
+			clause, _ := d.Limit(nil, nil, false)
+			buf.WriteString(clause)
+		case limitOrderByMark:
+			clause, _ := d.Limit(nil, nil, true)
+			buf.WriteString(clause)
+		case recursiveMark:
+			if _, ok := d.(sqlserverDialect); !ok {
+				buf.WriteString("RECURSIVE ")
+			}
+		case unionMark:
+			if _, ok := d.(sqliteDialect); ok {
+				buf.WriteString("SELECT * FROM ")
+			}
+			buf.WriteString("(")
+		}
+		sql = sql[i+1:]
+	}
+	buf.WriteString(sql)
+
+	return rebind(d, buf.String())
+}
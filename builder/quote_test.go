@@ -0,0 +1,152 @@
+package builder_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+func TestSQLBuilder_QuoteIdent(t *testing.T) {
+	cases := []struct {
+		dialect  builder.Dialect
+		expected string
+	}{
+		{builder.MySQL, "SELECT `t1`.`name`,`t1`.`age` AS `a`,`t2`.* FROM `db`.`test` AS `t1` WHERE `t1`.`id` = ? ORDER BY `t1`.`age` DESC"},
+		{builder.PostgreSQL, `SELECT "t1"."name","t1"."age" AS "a","t2".* FROM "db"."test" AS "t1" WHERE "t1"."id" = $1 ORDER BY "t1"."age" DESC`},
+		{builder.SQLServer, "SELECT [t1].[name],[t1].[age] AS [a],[t2].* FROM [db].[test] AS [t1] WHERE [t1].[id] = @p1 ORDER BY [t1].[age] DESC"},
+	}
+
+	for _, c := range cases {
+		//方言在标识符之后设置也能生效
+		sql, err := builder.NewSQLBuilder().
+			Table("db.test t1").
+			Select("t1.name", "t1.age as a", "t2.*").
+			Where("t1.id", "=", 1).
+			OrderBy("desc", "t1.age").
+			SetDialect(c.dialect).
+			GetQuerySql()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != c.expected {
+			t.Errorf("%s:\n got %s\nwant %s", c.dialect.Name(), sql, c.expected)
+		}
+	}
+}
+
+func TestSQLBuilder_QuotedIdentEscaped(t *testing.T) {
+	sb := builder.NewSQLBuilder().Table("`we``ird`").Select(`"a""b"`, "`t1`.`name`")
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "SELECT `a\"b`,`t1`.`name` FROM `we``ird`" {
+		t.Errorf("unexpected %s", sql)
+	}
+
+	sql, _ = sb.SetDialect(builder.PostgreSQL).GetQuerySql()
+	if sql != `SELECT "a""b","t1"."name" FROM "we`+"`"+`ird"` {
+		t.Errorf("unexpected %s", sql)
+	}
+}
+
+func TestSQLBuilder_UnsafeIdent(t *testing.T) {
+	for _, build := range []func(sb *builder.SQLBuilder) *builder.SQLBuilder{
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.Table("users; DROP TABLE users") },
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.Table("users").Select("COUNT(*)") },
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.Table("users").Select("`name") },
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder {
+			return sb.Table("users").Where("id = 1 OR 1", "=", 1)
+		},
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.Table("users").OrderBy("ASC", "id--") },
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.Table("users").GroupBy("a.b c d") },
+		func(sb *builder.SQLBuilder) *builder.SQLBuilder {
+			return sb.Table("users").WhereIn("id)", []interface{}{1})
+		},
+	} {
+		sb := build(builder.NewSQLBuilder())
+		if _, err := sb.GetQuerySql(); !errors.Is(err, builder.ErrUnsafeIdentifier) {
+			t.Errorf("expected ErrUnsafeIdentifier, got %v", err)
+		}
+	}
+
+	sb := builder.NewSQLBuilder().Table("users").Update([]string{"name = name"}, "x")
+	if _, err := sb.GetUpdateSql(); !errors.Is(err, builder.ErrUnsafeIdentifier) {
+		t.Errorf("expected ErrUnsafeIdentifier, got %v", err)
+	}
+}
+
+func TestSQLBuilder_OrderDirection(t *testing.T) {
+	_, err := builder.NewSQLBuilder().Table("users").OrderBy("DESC; DROP TABLE users", "id").GetQuerySql()
+	if !errors.Is(err, builder.ErrOrderDirection) {
+		t.Errorf("expected ErrOrderDirection, got %v", err)
+	}
+
+	sql, err := builder.NewSQLBuilder().Table("users").OrderBy("", "id", "name").GetQuerySql()
+	if err != nil || sql != "SELECT * FROM `users` ORDER BY `id`,`name`" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+}
+
+func TestSQLBuilder_Operator(t *testing.T) {
+	_, err := builder.NewSQLBuilder().Table("users").Where("id", "= 1 OR 1 =", 1).GetQuerySql()
+	if !errors.Is(err, builder.ErrOperator) {
+		t.Errorf("expected ErrOperator, got %v", err)
+	}
+
+	sql, err := builder.NewSQLBuilder().Table("users").Where("name", "not  like", "a%").GetQuerySql()
+	if err != nil || sql != "SELECT * FROM `users` WHERE `name` NOT LIKE ?" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+}
+
+func TestSQLBuilder_Having(t *testing.T) {
+	sql, err := builder.NewSQLBuilder().SetDialect(builder.PostgreSQL).Table("orders o").GroupBy("o.user_id").
+		Having("count(*)", ">", 1).
+		HavingOr("SUM(o.amount)", ">=", 100).
+		Having("COUNT(DISTINCT o.sku)", "<", 5).
+		Having("o.user_id", "<>", 0).
+		GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != `SELECT * FROM "orders" AS "o" GROUP BY "o"."user_id" HAVING COUNT(*) > $1 OR SUM("o"."amount") >= $2 AND COUNT(DISTINCT "o"."sku") < $3 AND "o"."user_id" <> $4` {
+		t.Errorf("unexpected %s", sql)
+	}
+
+	for _, field := range []string{"COUNT(id) > 0 OR 1", "SUM(amount) FROM users; --", "COUNT(a b)", "LENGTH(name)", "SUM(*)"} {
+		_, err := builder.NewSQLBuilder().Table("users").GroupBy("name").Having(field, ">", 1).GetQuerySql()
+		if !errors.Is(err, builder.ErrUnsafeIdentifier) {
+			t.Errorf("%s: expected ErrUnsafeIdentifier, got %v", field, err)
+		}
+	}
+
+	_, err = builder.NewSQLBuilder().Table("users").GroupBy("name").HavingOr("COUNT(*)", "> 1 OR 1 >", 1).GetQuerySql()
+	if !errors.Is(err, builder.ErrOperator) {
+		t.Errorf("expected ErrOperator, got %v", err)
+	}
+}
+
+func TestSQLBuilder_SelectRaw(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		SetDialect(builder.PostgreSQL).
+		Table("users").
+		Select("id").
+		SelectRaw("COALESCE(nick, ?) AS nick", "anon").
+		Where("id", ">", 3).
+		OrderByRaw("LENGTH(name) DESC")
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != `SELECT "id",COALESCE(nick, $1) AS nick FROM "users" WHERE "id" > $2 ORDER BY LENGTH(name) DESC` {
+		t.Errorf("unexpected %s", sql)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[anon 3]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
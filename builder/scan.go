@@ -0,0 +1,211 @@
+package builder
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrDestination = errors.New("destination must be a non-nil pointer")
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+
+	//每个结构体类型只解析一次
+	structCache sync.Map
+)
+
+// 结构体字段，嵌入结构体的字段下标路径有多级
+type structField struct {
+	index  []int
+	name   string
+	column string
+	tag    gdbTag
+}
+
+// list 按声明顺序保存字段，fields 为列名（小写）到字段下标路径
+type structInfo struct {
+	list   []structField
+	fields map[string][]int
+}
+
+// 扫描所有行到 dest 并关闭 rows，dest 为结构体切片、结构体指针切片或单列值切片的指针
+func ScanAll(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrDestination
+	}
+	slice := v.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	slice.Set(slice.Slice(0, 0))
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := scanRow(rows, columns, elem); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+// 扫描第一行到 dest 并关闭 rows，没有数据时返回 sql.ErrNoRows
+func ScanOne(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := ScanRow(rows, dest); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+// 扫描当前行，用于自己调用 rows.Next 的循环
+func ScanRow(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ErrDestination
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scanRow(rows, columns, v)
+}
+
+// ptr 指向结构体时按列名匹配字段，否则整行只能有一列
+func scanRow(rows *sql.Rows, columns []string, ptr reflect.Value) error {
+	elem := ptr.Elem()
+	if isLeaf(elem.Type()) {
+		return rows.Scan(ptr.Interface())
+	}
+
+	info := getStructInfo(elem.Type())
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		if index, ok := info.fields[strings.ToLower(column)]; ok {
+			targets[i] = fieldByIndex(elem, index).Addr().Interface()
+		} else {
+			//没有对应字段的列直接丢弃
+			targets[i] = new(sql.RawBytes)
+		}
+	}
+	return rows.Scan(targets...)
+}
+
+// 不需要按列拆分的类型，如基本类型、time.Time 和实现了 sql.Scanner 的类型
+func isLeaf(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	return t == timeType || reflect.PtrTo(t).Implements(scannerType)
+}
+
+// 嵌入结构体的指针为 nil 时自动分配
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// 读取字段值，嵌入结构体的指针为 nil 时返回零值
+func fieldValue(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Zero(v.Type().Elem().FieldByIndex(index[i:]).Type)
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func getStructInfo(t reflect.Type) *structInfo {
+	if info, ok := structCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+
+	info := &structInfo{fields: map[string][]int{}}
+	collectFields(t, nil, info, map[string]int{})
+
+	actual, _ := structCache.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+// 读写共用的字段解析，没有 column 标签的嵌入结构体展开其字段，
+// 同名时外层字段优先，同一层以先声明的为准，positions 记录列名在 list 中的位置
+func collectFields(t reflect.Type, parent []int, info *structInfo, positions map[string]int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := parseTag(f.Tag.Get("gdb"))
+		if tag.ignore {
+			continue
+		}
+
+		index := make([]int, len(parent)+1)
+		copy(index, parent)
+		index[len(parent)] = i
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !isLeaf(ft) && tag.column == "" {
+			//未导出的嵌入结构体指针无法分配
+			if f.PkgPath != "" && f.Type.Kind() == reflect.Ptr {
+				continue
+			}
+			collectFields(ft, index, info, positions)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		field := structField{index: index, name: f.Name, column: tag.columnName(f.Name), tag: tag}
+		name := strings.ToLower(field.column)
+		if pos, ok := positions[name]; ok {
+			if len(info.list[pos].index) <= len(index) {
+				continue
+			}
+			info.list[pos] = field
+		} else {
+			positions[name] = len(info.list)
+			info.list = append(info.list, field)
+		}
+		info.fields[name] = index
+	}
+}
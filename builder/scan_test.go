@@ -0,0 +1,175 @@
+package builder_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+// 逗号分隔的字符串
+type tagList []string
+
+func (l *tagList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+	case string:
+		*l = strings.Split(v, ",")
+	case []byte:
+		*l = strings.Split(string(v), ",")
+	default:
+		return fmt.Errorf("unsupported %T", src)
+	}
+	return nil
+}
+
+type Audit struct {
+	CreatedAt time.Time `gdb:"column:created_at"`
+	//被外层同名字段覆盖
+	Title string `gdb:"column:title"`
+}
+
+type Owner struct {
+	OwnerName string `gdb:"underline"`
+}
+
+type article struct {
+	Id     int64
+	Title  string `gdb:"column:title"`
+	Body   *string
+	Views  sql.NullInt64
+	Tags   tagList
+	Secret string `gdb:"ignore"`
+	Audit
+	*Owner
+	hidden string
+}
+
+func articleDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE articles (
+		id INTEGER PRIMARY KEY,
+		title TEXT,
+		body TEXT,
+		views INTEGER,
+		tags TEXT,
+		secret TEXT,
+		created_at DATETIME,
+		owner_name TEXT,
+		hidden TEXT
+	);
+	INSERT INTO articles VALUES
+		(1, 'first', 'hello', 10, 'a,b', 's', '2021-01-02 03:04:05', 'alice', 'h'),
+		(2, 'second', NULL, NULL, NULL, 's', '2021-02-03 04:05:06', '', 'h')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestScanAll(t *testing.T) {
+	db := articleDB(t)
+
+	rows, err := db.Query("SELECT * FROM articles ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []article
+	if err := builder.ScanAll(rows, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("unexpected %+v", list)
+	}
+
+	a := list[0]
+	if a.Id != 1 || a.Title != "first" || a.Body == nil || *a.Body != "hello" || a.Views.Int64 != 10 {
+		t.Errorf("unexpected %+v", a)
+	}
+	if len(a.Tags) != 2 || a.Tags[1] != "b" || a.Secret != "" || a.hidden != "" {
+		t.Errorf("unexpected %+v", a)
+	}
+	if a.Audit.Title != "" || !a.CreatedAt.Equal(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected audit %+v", a.Audit)
+	}
+	if a.Owner == nil || a.OwnerName != "alice" {
+		t.Errorf("unexpected owner %+v", a.Owner)
+	}
+
+	b := list[1]
+	if b.Body != nil || b.Views.Valid || b.Tags != nil || b.Owner == nil || b.OwnerName != "" {
+		t.Errorf("unexpected %+v", b)
+	}
+}
+
+func TestScanRow(t *testing.T) {
+	db := articleDB(t)
+
+	rows, err := db.Query("SELECT id, title, created_at FROM articles ORDER BY id DESC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		a := &article{}
+		if err := builder.ScanRow(rows, a); err != nil {
+			t.Fatal(err)
+		}
+		if a.CreatedAt.IsZero() || a.Owner != nil {
+			t.Errorf("unexpected %+v", a)
+		}
+		ids = append(ids, a.Id)
+	}
+	if fmt.Sprint(ids) != "[2 1]" {
+		t.Errorf("unexpected %v", ids)
+	}
+
+	var created time.Time
+	rows, _ = db.Query("SELECT created_at FROM articles WHERE id = 2")
+	if err := builder.ScanOne(rows, &created); err != nil || created.Month() != time.February {
+		t.Errorf("unexpected %v %v", created, err)
+	}
+
+	var views sql.NullInt64
+	rows, _ = db.Query("SELECT views FROM articles WHERE id = 2")
+	if err := builder.ScanOne(rows, &views); err != nil || views.Valid {
+		t.Errorf("unexpected %v %v", views, err)
+	}
+
+	rows, _ = db.Query("SELECT views FROM articles WHERE id = 3")
+	if err := builder.ScanOne(rows, &views); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestScan_Concurrent(t *testing.T) {
+	e := builder.NewExecutor(articleDB(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var list []*article
+			sb := builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("articles").Where("owner_name", "<>", "")
+			if err := e.Find(context.Background(), sb, &list); err != nil || len(list) != 1 || list[0].OwnerName != "alice" {
+				t.Errorf("unexpected %v %v", list, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
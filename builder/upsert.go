@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUpsertUnsupported = errors.New("upsert is not supported by this dialect")
+	ErrUpsertColumns     = errors.New("upsert requires conflict or update columns")
+)
+
+const (
+	upsertUpdate = iota + 1
+	upsertNothing
+	upsertIgnore
+)
+
+// 冲突列，PostgreSQL 和 SQLite 的 DO UPDATE 必须指定，MySQL 按任意唯一键判断冲突，
+// 只用于 MySQL 的 DoNothing
+func (sb *SQLBuilder) OnConflict(columns ...string) *SQLBuilder {
+	sb._conflict = sb.idents(columns)
+	return sb
+}
+
+// 冲突时用插入的值更新这些列，
+// MySQL 生成 ON DUPLICATE KEY UPDATE，PostgreSQL、SQLite 生成 ON CONFLICT (...) DO UPDATE
+func (sb *SQLBuilder) DoUpdate(columns ...string) *SQLBuilder {
+	sb._upsert = upsertUpdate
+	sb._upsertUpdate = sb.idents(columns)
+	return sb
+}
+
+// 冲突时保留原有数据，只忽略唯一键冲突，
+// MySQL 会把第一个冲突列赋值为自身，需要先调用 OnConflict
+func (sb *SQLBuilder) DoNothing() *SQLBuilder {
+	sb._upsert = upsertNothing
+	return sb
+}
+
+// 忽略插入时的错误，MySQL 为 INSERT IGNORE，SQLite 为 INSERT OR IGNORE，
+// PostgreSQL 为 ON CONFLICT DO NOTHING
+func (sb *SQLBuilder) InsertIgnore() *SQLBuilder {
+	sb._upsert = upsertIgnore
+	return sb
+}
+
+// MySQL 8.0.19 起用行别名引用插入的值，代替已废弃的 VALUES()
+func (sb *SQLBuilder) UpsertAlias(alias string) *SQLBuilder {
+	a, err := markPart(alias)
+	if err != nil {
+		sb.setErr(fmt.Errorf("%w: %q", ErrUnsafeIdentifier, alias))
+		return sb
+	}
+	sb._upsertAlias = a
+	return sb
+}
+
+// 冲突时更新 update 中的列，没有可更新的列时忽略冲突
+func (sb *SQLBuilder) upsert(conflict []string, update []string) *SQLBuilder {
+	sb.OnConflict(conflict...)
+	if len(update) == 0 {
+		return sb.DoNothing()
+	}
+	return sb.DoUpdate(update...)
+}
+
+// INSERT 的开头和跟在 VALUES 之后的冲突子句，依赖方言，在生成 SQL 时确定
+func (sb *SQLBuilder) upsertClause() (string, string, error) {
+	if sb._upsert == 0 {
+		return "INSERT INTO ", "", nil
+	}
+
+	switch sb.getDialect().Name() {
+	case "mysql":
+		return sb.mysqlUpsert()
+	case "postgres", "sqlite":
+		return sb.conflictUpsert()
+	default:
+		return "", "", ErrUpsertUnsupported
+	}
+}
+
+func (sb *SQLBuilder) mysqlUpsert() (string, string, error) {
+	if sb._upsert == upsertIgnore {
+		return "INSERT IGNORE INTO ", "", nil
+	}
+
+	var buf strings.Builder
+	if sb._upsertAlias != "" {
+		buf.WriteString(" AS ")
+		buf.WriteString(sb._upsertAlias)
+	}
+	buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+
+	if sb._upsert == upsertNothing || len(sb._upsertUpdate) == 0 {
+		if len(sb._conflict) == 0 {
+			return "", "", ErrUpsertColumns
+		}
+		//原样赋值，相当于忽略冲突
+		buf.WriteString(sb._conflict[0] + " = " + sb._conflict[0])
+		return "INSERT INTO ", buf.String(), nil
+	}
+
+	for i, col := range sb._upsertUpdate {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		if sb._upsertAlias != "" {
+			buf.WriteString(col + " = " + sb._upsertAlias + "." + col)
+		} else {
+			buf.WriteString(col + " = VALUES(" + col + ")")
+		}
+	}
+	return "INSERT INTO ", buf.String(), nil
+}
+
+func (sb *SQLBuilder) conflictUpsert() (string, string, error) {
+	if sb._upsert == upsertIgnore && sb.getDialect().Name() == "sqlite" {
+		return "INSERT OR IGNORE INTO ", "", nil
+	}
+
+	var buf strings.Builder
+	buf.WriteString(" ON CONFLICT")
+	if len(sb._conflict) > 0 {
+		buf.WriteString(" (")
+		buf.WriteString(strings.Join(sb._conflict, ","))
+		buf.WriteString(")")
+	}
+
+	if sb._upsert != upsertUpdate || len(sb._upsertUpdate) == 0 {
+		buf.WriteString(" DO NOTHING")
+		return "INSERT INTO ", buf.String(), nil
+	}
+
+	if len(sb._conflict) == 0 {
+		return "", "", ErrUpsertColumns
+	}
+	buf.WriteString(" DO UPDATE SET ")
+	for i, col := range sb._upsertUpdate {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(col + " = EXCLUDED." + col)
+	}
+	return "INSERT INTO ", buf.String(), nil
+}
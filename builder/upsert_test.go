@@ -0,0 +1,124 @@
+package builder_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+func TestSQLBuilder_Upsert(t *testing.T) {
+	insert := func(d builder.Dialect) *builder.SQLBuilder {
+		return builder.NewSQLBuilder().SetDialect(d).Table("users").
+			Insert([]string{"id", "name", "age"}, 1, "a", 2)
+	}
+	insertAll := func(d builder.Dialect) *builder.SQLBuilder {
+		return builder.NewSQLBuilder().SetDialect(d).Table("users").
+			InsertAll([]string{"id", "name"}, []interface{}{1, "a"}, []interface{}{2, "b"})
+	}
+
+	for _, c := range []struct {
+		name string
+		sb   *builder.SQLBuilder
+		all  bool
+		sql  string
+	}{
+		{"mysql update", insert(builder.MySQL).OnConflict("id").DoUpdate("name", "age"), false,
+			"INSERT INTO `users` (`id`,`name`,`age`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`),`age` = VALUES(`age`)"},
+		{"mysql alias", insertAll(builder.MySQL).DoUpdate("name").UpsertAlias("new"), true,
+			"INSERT INTO `users` (`id`,`name`) VALUES (?,?),(?,?) AS `new` ON DUPLICATE KEY UPDATE `name` = `new`.`name`"},
+		{"mysql nothing", insert(builder.MySQL).OnConflict("id").DoNothing(), false,
+			"INSERT INTO `users` (`id`,`name`,`age`) VALUES (?,?,?) ON DUPLICATE KEY UPDATE `id` = `id`"},
+		{"mysql ignore", insertAll(builder.MySQL).InsertIgnore(), true,
+			"INSERT IGNORE INTO `users` (`id`,`name`) VALUES (?,?),(?,?)"},
+		{"postgres update", insertAll(builder.PostgreSQL).OnConflict("id").DoUpdate("name"), true,
+			`INSERT INTO "users" ("id","name") VALUES ($1,$2),($3,$4) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`},
+		{"postgres nothing", insert(builder.PostgreSQL).DoNothing(), false,
+			`INSERT INTO "users" ("id","name","age") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`},
+		{"postgres ignore", insert(builder.PostgreSQL).OnConflict("id").InsertIgnore(), false,
+			`INSERT INTO "users" ("id","name","age") VALUES ($1,$2,$3) ON CONFLICT ("id") DO NOTHING`},
+		{"sqlite update", insert(builder.SQLite).OnConflict("id", "name").DoUpdate("age"), false,
+			`INSERT INTO "users" ("id","name","age") VALUES (?,?,?) ON CONFLICT ("id","name") DO UPDATE SET "age" = EXCLUDED."age"`},
+		{"sqlite ignore", insertAll(builder.SQLite).InsertIgnore(), true,
+			`INSERT OR IGNORE INTO "users" ("id","name") VALUES (?,?),(?,?)`},
+	} {
+		var sql string
+		var err error
+		if c.all {
+			sql, err = c.sb.GetInsertAllSql()
+		} else {
+			sql, err = c.sb.GetInsertSql()
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if sql != c.sql {
+			t.Errorf("%s:\n got %s\nwant %s", c.name, sql, c.sql)
+		}
+	}
+}
+
+func TestSQLBuilder_UpsertErrors(t *testing.T) {
+	sb := builder.NewSQLBuilder().SetDialect(builder.PostgreSQL).Table("users").Insert([]string{"id"}, 1).DoUpdate("id")
+	if _, err := sb.GetInsertSql(); err != builder.ErrUpsertColumns {
+		t.Errorf("expected ErrUpsertColumns, got %v", err)
+	}
+
+	sb = builder.NewSQLBuilder().Table("users").Insert([]string{"id"}, 1).DoNothing()
+	if _, err := sb.GetInsertSql(); err != builder.ErrUpsertColumns {
+		t.Errorf("expected ErrUpsertColumns, got %v", err)
+	}
+
+	sb = builder.NewSQLBuilder().SetDialect(builder.SQLServer).Table("users").Insert([]string{"id"}, 1).InsertIgnore()
+	if _, err := sb.GetInsertSql(); err != builder.ErrUpsertUnsupported {
+		t.Errorf("expected ErrUpsertUnsupported, got %v", err)
+	}
+
+	//没有冲突处理时 SQL Server 正常生成
+	sb = builder.NewSQLBuilder().SetDialect(builder.SQLServer).Table("users").Insert([]string{"id"}, 1)
+	if sql, err := sb.GetInsertSql(); err != nil || sql != "INSERT INTO [users] ([id]) VALUES (@p1)" {
+		t.Errorf("unexpected %s %v", sql, err)
+	}
+}
+
+func TestSQLBuilder_UpsertSQLite(t *testing.T) {
+	e := builder.NewExecutor(openDB(t))
+	seed(t, e)
+	ctx := context.Background()
+
+	newSB := func() *builder.SQLBuilder {
+		return builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users")
+	}
+	rows := func() string {
+		var list []execUser
+		if err := e.Find(ctx, newSB().OrderBy("ASC", "id"), &list); err != nil {
+			t.Fatal(err)
+		}
+		s := ""
+		for _, u := range list {
+			s += fmt.Sprintf("%d:%s:%d ", u.Id, u.Name, u.UserAge)
+		}
+		return s
+	}
+
+	fields := []string{"id", "user_name", "user_age"}
+	if _, err := e.InsertAll(ctx, newSB().
+		InsertAll(fields, []interface{}{1, "alice", 31}, []interface{}{4, "dave", 40}).
+		OnConflict("id").DoUpdate("user_age")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Insert(ctx, newSB().Insert(fields, 2, "robert", 26).OnConflict("id").DoNothing()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.InsertAll(ctx, newSB().
+		InsertAll(fields, []interface{}{3, "caroline", 36}, []interface{}{5, "eve", 50}).
+		InsertIgnore()); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := rows(); s != "1:alice:31 2:bob:25 3:carol:35 4:dave:40 5:eve:50 " {
+		t.Errorf("unexpected %s", s)
+	}
+}
@@ -0,0 +1,167 @@
+package builder
+
+import "strings"
+
+// WhereContains 等按字面量匹配的条件使用 ! 作为转义字符
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// 转义 LIKE 模式中的 %、_ 和转义字符本身
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+func (sb *SQLBuilder) between(field string, condition string) string {
+	return sb.ident(field) + " " + condition + " ? AND ?"
+}
+
+func (sb *SQLBuilder) null(field string, condition string) string {
+	return sb.ident(field) + " " + condition
+}
+
+func (sb *SQLBuilder) like(field string, condition string) string {
+	return sb.ident(field) + " " + condition + " ?"
+}
+
+// 模式经过 escapeLike 转义时才声明转义字符，原样传入的模式不受影响
+func (sb *SQLBuilder) likeEscaped(field string) string {
+	return sb.ident(field) + " LIKE ? ESCAPE '!'"
+}
+
+// 嵌入子查询，子查询的错误记录到当前 SQLBuilder
+func (sb *SQLBuilder) sub(sub *SQLBuilder) (string, []interface{}) {
+	sql, err := sub.querySql()
+	if err != nil {
+		sb.setErr(err)
+		return "", nil
+	}
+	return "(" + sql + ")", sub.queryParams()
+}
+
+func (sb *SQLBuilder) exists(condition string, sub *SQLBuilder) (string, []interface{}) {
+	sql, params := sb.sub(sub)
+	return condition + " " + sql, params
+}
+
+func (sb *SQLBuilder) inSub(field string, condition string, sub *SQLBuilder) (string, []interface{}) {
+	sql, params := sb.sub(sub)
+	return sb.ident(field) + " " + condition + " " + sql, params
+}
+
+// field BETWEEN ? AND ?
+func (sb *SQLBuilder) WhereBetween(field string, from, to interface{}) *SQLBuilder {
+	return sb.addWhere("AND", sb.between(field, "BETWEEN"), from, to)
+}
+
+func (sb *SQLBuilder) WhereNotBetween(field string, from, to interface{}) *SQLBuilder {
+	return sb.addWhere("AND", sb.between(field, "NOT BETWEEN"), from, to)
+}
+
+func (sb *SQLBuilder) WhereNull(field string) *SQLBuilder {
+	return sb.addWhere("AND", sb.null(field, "IS NULL"))
+}
+
+func (sb *SQLBuilder) WhereNotNull(field string) *SQLBuilder {
+	return sb.addWhere("AND", sb.null(field, "IS NOT NULL"))
+}
+
+// pattern 原样传给数据库，% 和 _ 作为通配符，需要按字面量匹配时使用 WhereContains 等方法
+func (sb *SQLBuilder) WhereLike(field string, pattern string) *SQLBuilder {
+	return sb.addWhere("AND", sb.like(field, "LIKE"), pattern)
+}
+
+func (sb *SQLBuilder) WhereNotLike(field string, pattern string) *SQLBuilder {
+	return sb.addWhere("AND", sb.like(field, "NOT LIKE"), pattern)
+}
+
+// 包含 s，s 中的 % 和 _ 按字面量匹配
+func (sb *SQLBuilder) WhereContains(field string, s string) *SQLBuilder {
+	return sb.addWhere("AND", sb.likeEscaped(field), "%"+escapeLike(s)+"%")
+}
+
+func (sb *SQLBuilder) WhereStartsWith(field string, s string) *SQLBuilder {
+	return sb.addWhere("AND", sb.likeEscaped(field), escapeLike(s)+"%")
+}
+
+func (sb *SQLBuilder) WhereEndsWith(field string, s string) *SQLBuilder {
+	return sb.addWhere("AND", sb.likeEscaped(field), "%"+escapeLike(s))
+}
+
+// EXISTS (子查询)，子查询按当前 SQLBuilder 的方言生成
+func (sb *SQLBuilder) WhereExists(sub *SQLBuilder) *SQLBuilder {
+	expr, params := sb.exists("EXISTS", sub)
+	return sb.addWhere("AND", expr, params...)
+}
+
+func (sb *SQLBuilder) WhereNotExists(sub *SQLBuilder) *SQLBuilder {
+	expr, params := sb.exists("NOT EXISTS", sub)
+	return sb.addWhere("AND", expr, params...)
+}
+
+// field IN (子查询)
+func (sb *SQLBuilder) WhereInSub(field string, sub *SQLBuilder) *SQLBuilder {
+	expr, params := sb.inSub(field, "IN", sub)
+	return sb.addWhere("AND", expr, params...)
+}
+
+func (sb *SQLBuilder) WhereNotInSub(field string, sub *SQLBuilder) *SQLBuilder {
+	expr, params := sb.inSub(field, "NOT IN", sub)
+	return sb.addWhere("AND", expr, params...)
+}
+
+// 以下为分组内的同名条件，需要 OR 时使用 WhereOrGroup 包裹
+
+func (c *Cond) WhereBetween(field string, from, to interface{}) *Cond {
+	return c.add("AND", c.sb.between(field, "BETWEEN"), from, to)
+}
+
+func (c *Cond) WhereNotBetween(field string, from, to interface{}) *Cond {
+	return c.add("AND", c.sb.between(field, "NOT BETWEEN"), from, to)
+}
+
+func (c *Cond) WhereNull(field string) *Cond {
+	return c.add("AND", c.sb.null(field, "IS NULL"))
+}
+
+func (c *Cond) WhereNotNull(field string) *Cond {
+	return c.add("AND", c.sb.null(field, "IS NOT NULL"))
+}
+
+func (c *Cond) WhereLike(field string, pattern string) *Cond {
+	return c.add("AND", c.sb.like(field, "LIKE"), pattern)
+}
+
+func (c *Cond) WhereNotLike(field string, pattern string) *Cond {
+	return c.add("AND", c.sb.like(field, "NOT LIKE"), pattern)
+}
+
+func (c *Cond) WhereContains(field string, s string) *Cond {
+	return c.add("AND", c.sb.likeEscaped(field), "%"+escapeLike(s)+"%")
+}
+
+func (c *Cond) WhereStartsWith(field string, s string) *Cond {
+	return c.add("AND", c.sb.likeEscaped(field), escapeLike(s)+"%")
+}
+
+func (c *Cond) WhereEndsWith(field string, s string) *Cond {
+	return c.add("AND", c.sb.likeEscaped(field), "%"+escapeLike(s))
+}
+
+func (c *Cond) WhereExists(sub *SQLBuilder) *Cond {
+	expr, params := c.sb.exists("EXISTS", sub)
+	return c.add("AND", expr, params...)
+}
+
+func (c *Cond) WhereNotExists(sub *SQLBuilder) *Cond {
+	expr, params := c.sb.exists("NOT EXISTS", sub)
+	return c.add("AND", expr, params...)
+}
+
+func (c *Cond) WhereInSub(field string, sub *SQLBuilder) *Cond {
+	expr, params := c.sb.inSub(field, "IN", sub)
+	return c.add("AND", expr, params...)
+}
+
+func (c *Cond) WhereNotInSub(field string, sub *SQLBuilder) *Cond {
+	expr, params := c.sb.inSub(field, "NOT IN", sub)
+	return c.add("AND", expr, params...)
+}
@@ -0,0 +1,164 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/xuyang404/goutils/builder"
+)
+
+func TestSQLBuilder_WhereOperators(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		Table("users").
+		WhereBetween("age", 18, 30).
+		WhereNotBetween("score", 0, 10).
+		WhereNull("deleted_at").
+		WhereNotNull("email").
+		WhereLike("name", "a%").
+		WhereNotLike("nick", "%x").
+		WhereContains("bio", "100%_sure!")
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "SELECT * FROM `users` WHERE `age` BETWEEN ? AND ? AND `score` NOT BETWEEN ? AND ? AND `deleted_at` IS NULL " +
+		"AND `email` IS NOT NULL AND `name` LIKE ? AND `nick` NOT LIKE ? AND `bio` LIKE ? ESCAPE '!'"
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[18 30 0 10 a% %x %100!%!_sure!!%]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_WhereContains(t *testing.T) {
+	sb := builder.NewSQLBuilder().Table("t").WhereStartsWith("a", "x_").WhereEndsWith("b", "%y").WhereContains("c", "!%_!_")
+	if fmt.Sprint(sb.GetQueryParams()) != "[x!_% %!%y %!!!%!_!!!_%]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+// WhereLike 的模式原样使用，WhereContains 等按字面量匹配
+func TestSQLBuilder_LikeSQLite(t *testing.T) {
+	db := openDB(t)
+	e := builder.NewExecutor(db)
+	ctx := context.Background()
+	insert := builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").
+		InsertAll([]string{"user_name", "user_age"},
+			[]interface{}{"hi!", 1},
+			[]interface{}{"100%", 2},
+			[]interface{}{"1000", 3},
+			[]interface{}{"a_b", 4},
+			[]interface{}{"axb", 5})
+	if _, err := e.InsertAll(ctx, insert); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		where    func(sb *builder.SQLBuilder) *builder.SQLBuilder
+		expected string
+	}{
+		{func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.WhereLike("user_name", "hi!") }, "[1]"},
+		{func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.WhereLike("user_name", "100%") }, "[2 3]"},
+		{func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.WhereNotLike("user_name", "a_b") }, "[1 2 3]"},
+		{func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.WhereStartsWith("user_name", "100%") }, "[2]"},
+		{func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.WhereContains("user_name", "_") }, "[4]"},
+		{func(sb *builder.SQLBuilder) *builder.SQLBuilder { return sb.WhereEndsWith("user_name", "i!") }, "[1]"},
+	} {
+		sb := c.where(builder.NewSQLBuilder().SetDialect(builder.SQLite).Table("users").Select("user_age").OrderBy("ASC", "id"))
+		var ages []int
+		if err := e.Find(ctx, sb, &ages); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(ages) != c.expected {
+			sql, _ := sb.GetQuerySql()
+			t.Errorf("%s %v: got %v, expected %s", sql, sb.GetQueryParams(), ages, c.expected)
+		}
+	}
+}
+
+func TestSQLBuilder_WhereEmptyIn(t *testing.T) {
+	sb := builder.NewSQLBuilder().
+		Table("users").
+		WhereIn("id", nil).
+		WhereOrNotIn("id", []interface{}{}).
+		Where("age", ">", 1)
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "SELECT * FROM `users` WHERE 1 = 0 OR 1 = 1 AND `age` > ?" {
+		t.Errorf("unexpected %s", sql)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[1]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_WhereSubquery(t *testing.T) {
+	orders := builder.NewSQLBuilder().
+		Table("orders o").
+		Select("o.id").
+		Where("o.amount", ">", 100).
+		WhereRaw("o.user_id = u.id", nil)
+	banned := builder.NewSQLBuilder().
+		Table("bans").
+		Select("user_id").
+		Where("reason", "=", "spam").
+		OrderBy("DESC", "created_at").
+		Limit(0, 50)
+
+	sb := builder.NewSQLBuilder().
+		Table("users u").
+		Where("u.age", ">", 18).
+		WhereExists(orders).
+		WhereNotInSub("u.id", banned).
+		WhereGroup(func(c *builder.Cond) {
+			c.WhereNull("u.deleted_at").WhereInSub("u.id", builder.NewSQLBuilder().Table("vip").Select("user_id"))
+		}).
+		Limit(10, 20).
+		SetDialect(builder.PostgreSQL)
+
+	sql, err := sb.GetQuerySql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT * FROM "users" AS "u" WHERE "u"."age" > $1 ` +
+		`AND EXISTS (SELECT "o"."id" FROM "orders" AS "o" WHERE "o"."amount" > $2 AND o.user_id = u.id) ` +
+		`AND "u"."id" NOT IN (SELECT "user_id" FROM "bans" WHERE "reason" = $3 ORDER BY "created_at" DESC LIMIT $4 OFFSET $5) ` +
+		`AND ("u"."deleted_at" IS NULL AND "u"."id" IN (SELECT "user_id" FROM "vip")) LIMIT $6 OFFSET $7`
+	if sql != expected {
+		t.Errorf("\n got %s\nwant %s", sql, expected)
+	}
+	if fmt.Sprint(sb.GetQueryParams()) != "[18 100 spam 50 0 20 10]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+
+	//MySQL 的分页参数顺序不同
+	sb.SetDialect(builder.MySQL)
+	if fmt.Sprint(sb.GetQueryParams()) != "[18 100 spam 0 50 10 20]" {
+		t.Errorf("unexpected params %v", sb.GetQueryParams())
+	}
+}
+
+func TestSQLBuilder_WhereSubqueryError(t *testing.T) {
+	_, err := builder.NewSQLBuilder().
+		Table("users").
+		WhereExists(builder.NewSQLBuilder().Select("id")).
+		GetQuerySql()
+	if err != builder.ErrTableEmpty {
+		t.Errorf("expected ErrTableEmpty, got %v", err)
+	}
+
+	_, err = builder.NewSQLBuilder().
+		Table("users").
+		WhereInSub("id", builder.NewSQLBuilder().Table("t").OrderBy("sideways", "id")).
+		GetDeleteSql()
+	if !errors.Is(err, builder.ErrOrderDirection) {
+		t.Errorf("expected ErrOrderDirection, got %v", err)
+	}
+}
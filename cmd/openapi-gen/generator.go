@@ -0,0 +1,761 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+type generator struct {
+	spec    *Spec
+	pkg     string
+	types   map[string]string
+	structs map[string]bool
+	methods bytes.Buffer
+}
+
+type param struct {
+	name     string
+	in       string
+	field    string
+	arg      string
+	typ      string
+	required bool
+	doc      string
+}
+
+// 根据 OpenAPI 文档生成基于 HttpClient 的客户端代码
+func Generate(spec *Spec, pkg string) ([]byte, error) {
+	g := &generator{
+		spec:    spec,
+		pkg:     pkg,
+		types:   map[string]string{},
+		structs: map[string]bool{},
+	}
+
+	for _, name := range sortedSchemaKeys(spec.Components.Schemas) {
+		if err := g.defineNamed(goName(name), spec.Components.Schemas[name]); err != nil {
+			return nil, fmt.Errorf("schema %s: %v", name, err)
+		}
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	seen := map[string]string{}
+	for _, path := range paths {
+		item := spec.Paths[path]
+		for _, method := range methods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+			name := operationName(method, path, op)
+			if prev, ok := seen[name]; ok {
+				return nil, fmt.Errorf("%s %s: method name %s is already used by %s", method, path, name, prev)
+			}
+			seen[name] = method + " " + path
+			if err := g.operation(method, path, name, item, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", method, path, err)
+			}
+		}
+	}
+
+	return g.source()
+}
+
+func (p *PathItem) operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return p.Get
+	case "POST":
+		return p.Post
+	case "PUT":
+		return p.Put
+	case "PATCH":
+		return p.Patch
+	case "DELETE":
+		return p.Delete
+	}
+	return nil
+}
+
+var importPatterns = []struct {
+	path    string
+	pattern *regexp.Regexp
+}{
+	{"fmt", regexp.MustCompile(`\bfmt\.`)},
+	{"net/url", regexp.MustCompile(`\burl\.`)},
+	{"strings", regexp.MustCompile(`\bstrings\.`)},
+	{"time", regexp.MustCompile(`\btime\.`)},
+}
+
+func (g *generator) source() ([]byte, error) {
+	var body bytes.Buffer
+
+	title := strings.TrimSpace(g.spec.Info.Title + " " + g.spec.Info.Version)
+	if len(g.spec.Servers) > 0 {
+		fmt.Fprintf(&body, "// DefaultBaseUrl is the first server declared in the spec.\n")
+		fmt.Fprintf(&body, "const DefaultBaseUrl = %q\n\n", strings.TrimSuffix(g.spec.Servers[0].Url, "/"))
+	}
+	if title != "" {
+		fmt.Fprintf(&body, "// Client calls the %s API.\n", title)
+	}
+	body.WriteString(`type Client struct {
+	BaseUrl string
+	// NewRequest creates the HttpClient.Request used by each call; replace it to
+	// configure timeouts, auth, middlewares and so on.
+	NewRequest func() *HttpClient.Request
+}
+
+func NewClient(baseUrl string) *Client {
+	return &Client{
+		BaseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		NewRequest: HttpClient.NewRequest,
+	}
+}
+
+`)
+	body.Write(g.methods.Bytes())
+
+	names := make([]string, 0, len(g.types))
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		body.WriteString(g.types[name])
+		body.WriteString("\n")
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by openapi-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", g.pkg)
+	for _, imp := range importPatterns {
+		if imp.pattern.Match(body.Bytes()) {
+			fmt.Fprintf(&out, "\t%q\n", imp.path)
+		}
+	}
+	out.WriteString("\n\t\"github.com/xuyang404/goutils/HttpClient\"\n)\n\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) operation(method, path, name string, item *PathItem, op *Operation) error {
+	params, err := g.params(name, item, op)
+	if err != nil {
+		return err
+	}
+
+	var pathParams, optParams []*param
+	for _, p := range params {
+		if p.in == "path" {
+			pathParams = append(pathParams, p)
+		} else {
+			optParams = append(optParams, p)
+		}
+	}
+
+	bodyType := ""
+	requestBody, err := g.spec.requestBody(op.RequestBody)
+	if err != nil {
+		return err
+	}
+	if requestBody != nil {
+		schema, ok := jsonSchema(requestBody.Content)
+		if !ok {
+			return fmt.Errorf("only application/json request bodies are supported")
+		}
+		if bodyType, err = g.goType(schema, name+"Request"); err != nil {
+			return err
+		}
+	}
+
+	respType, err := g.responseType(name, op)
+	if err != nil {
+		return err
+	}
+
+	paramsType := ""
+	if len(optParams) > 0 {
+		paramsType = name + "Params"
+		g.defineParams(paramsType, optParams)
+	}
+
+	args := make([]string, 0)
+	for _, p := range pathParams {
+		args = append(args, p.arg+" "+p.typ)
+	}
+	if bodyType != "" {
+		args = append(args, "body "+bodyType)
+	}
+	if paramsType != "" {
+		args = append(args, "params *"+paramsType)
+	}
+
+	w := &g.methods
+	writeDoc(w, name, firstNonEmpty(op.Summary, op.Description))
+	if op.Summary != "" && op.Description != "" {
+		w.WriteString("//\n")
+		writeComment(w, "", op.Description)
+	}
+	if op.Summary != "" || op.Description != "" {
+		w.WriteString("//\n")
+	}
+	fmt.Fprintf(w, "// %s %s\n", method, path)
+	if op.Deprecated {
+		w.WriteString("//\n// Deprecated: the operation is deprecated in the spec.\n")
+	}
+
+	if respType != "" {
+		fmt.Fprintf(w, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), respType)
+	} else {
+		fmt.Fprintf(w, "func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	}
+
+	fmt.Fprintf(w, "\treqUrl := c.BaseUrl + %s\n", pathExpr(path, pathParams))
+	w.WriteString("\treq := c.NewRequest()\n")
+	if paramsType != "" {
+		w.WriteString("\tif params != nil {\n")
+		if hasParamIn(optParams, "query") {
+			w.WriteString("\t\tif q := params.query().Encode(); q != \"\" {\n\t\t\treqUrl += \"?\" + q\n\t\t}\n")
+		}
+		if hasParamIn(optParams, "header") {
+			w.WriteString("\t\treq.AddHeaders(params.headers())\n")
+		}
+		w.WriteString("\t}\n")
+	}
+
+	result := respType
+	if result == "" {
+		result = "HttpClient.NoContent"
+	}
+	call := fmt.Sprintf("HttpClient.DoJSON[%s](req, %q, reqUrl, nil)", result, method)
+	if bodyType != "" {
+		call = fmt.Sprintf("HttpClient.SendJSON[%s, %s](req, %q, reqUrl, body)", bodyType, result, method)
+	}
+	if respType != "" {
+		fmt.Fprintf(w, "\treturn %s\n}\n\n", call)
+	} else {
+		fmt.Fprintf(w, "\t_, err := %s\n\treturn err\n}\n\n", call)
+	}
+
+	return nil
+}
+
+// 合并路径级和操作级参数，操作级参数覆盖同名参数
+func (g *generator) params(opName string, item *PathItem, op *Operation) ([]*param, error) {
+	list := make([]*Parameter, 0)
+	index := map[string]int{}
+	for _, raw := range append(append([]*Parameter{}, item.Parameters...), op.Parameters...) {
+		p, err := g.spec.parameter(raw)
+		if err != nil {
+			return nil, err
+		}
+		key := p.In + ":" + p.Name
+		if i, ok := index[key]; ok {
+			list[i] = p
+			continue
+		}
+		index[key] = len(list)
+		list = append(list, p)
+	}
+
+	params := make([]*param, 0)
+	used := map[string]bool{"reqUrl": true, "req": true, "body": true, "params": true, "c": true, "err": true,
+		"url": true, "fmt": true, "strings": true, "time": true}
+	for _, p := range list {
+		switch p.In {
+		case "path", "query", "header":
+		case "cookie":
+			continue
+		default:
+			return nil, fmt.Errorf("parameter %s: unsupported location %q", p.Name, p.In)
+		}
+
+		typ, err := g.goType(p.Schema, opName+goName(p.Name))
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %v", p.Name, err)
+		}
+
+		arg := lowerName(goName(p.Name))
+		if token.IsKeyword(arg) || used[arg] {
+			arg += "Param"
+		}
+		used[arg] = true
+
+		params = append(params, &param{
+			name:     p.Name,
+			in:       p.In,
+			field:    goName(p.Name),
+			arg:      arg,
+			typ:      typ,
+			required: p.Required || p.In == "path",
+			doc:      p.Description,
+		})
+	}
+	return params, nil
+}
+
+func (g *generator) responseType(opName string, op *Operation) (string, error) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	chosen := ""
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			chosen = code
+			break
+		}
+	}
+	if chosen == "" {
+		if _, ok := op.Responses["default"]; !ok {
+			return "", nil
+		}
+		chosen = "default"
+	}
+
+	res, err := g.spec.response(op.Responses[chosen])
+	if err != nil || res == nil {
+		return "", err
+	}
+	schema, ok := jsonSchema(res.Content)
+	if !ok {
+		return "", nil
+	}
+	return g.goType(schema, opName+"Response")
+}
+
+func jsonSchema(content map[string]*MediaType) (*Schema, bool) {
+	keys := make([]string, 0, len(content))
+	for k := range content {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.Contains(k, "json") && content[k] != nil {
+			return content[k].Schema, true
+		}
+	}
+	return nil, false
+}
+
+func pathExpr(path string, params []*param) string {
+	byName := map[string]*param{}
+	for _, p := range params {
+		byName[p.name] = p
+	}
+
+	parts := make([]string, 0)
+	for path != "" {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			parts = append(parts, fmt.Sprintf("%q", path))
+			break
+		}
+		if start > 0 {
+			parts = append(parts, fmt.Sprintf("%q", path[:start]))
+		}
+		name := path[start+1 : end]
+		if p, ok := byName[name]; ok {
+			if p.typ == "string" {
+				parts = append(parts, "url.PathEscape("+p.arg+")")
+			} else {
+				parts = append(parts, "url.PathEscape(fmt.Sprint("+p.arg+"))")
+			}
+		} else {
+			parts = append(parts, fmt.Sprintf("%q", path[start:end+1]))
+		}
+		path = path[end+1:]
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " + ")
+}
+
+func hasParamIn(params []*param, in string) bool {
+	for _, p := range params {
+		if p.in == in {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) defineParams(name string, params []*param) {
+	var w bytes.Buffer
+
+	fmt.Fprintf(&w, "// %s holds the query and header parameters of %s.\n", name, strings.TrimSuffix(name, "Params"))
+	fmt.Fprintf(&w, "type %s struct {\n", name)
+	for _, p := range params {
+		writeComment(&w, "\t", p.doc)
+		fmt.Fprintf(&w, "\t%s %s\n", p.field, p.fieldType())
+	}
+	w.WriteString("}\n\n")
+
+	fmt.Fprintf(&w, "func New%s() *%s {\n\treturn &%s{}\n}\n\n", name, name, name)
+	for _, p := range params {
+		fmt.Fprintf(&w, "func (p *%s) Set%s(v %s) *%s {\n", name, p.field, p.typ, name)
+		if p.pointer() {
+			fmt.Fprintf(&w, "\tp.%s = &v\n", p.field)
+		} else {
+			fmt.Fprintf(&w, "\tp.%s = v\n", p.field)
+		}
+		w.WriteString("\treturn p\n}\n\n")
+	}
+
+	for _, in := range []string{"query", "header"} {
+		if !hasParamIn(params, in) {
+			continue
+		}
+		if in == "query" {
+			fmt.Fprintf(&w, "func (p *%s) query() url.Values {\n\tq := url.Values{}\n", name)
+		} else {
+			fmt.Fprintf(&w, "func (p *%s) headers() map[string]string {\n\th := map[string]string{}\n", name)
+		}
+		for _, p := range params {
+			if p.in != in {
+				continue
+			}
+			set := fmt.Sprintf("h[%q] = %%s\n", p.name)
+			if in == "query" {
+				set = fmt.Sprintf("q.Add(%q, %%s)\n", p.name)
+			}
+			switch {
+			case strings.HasPrefix(p.typ, "[]") && in == "query":
+				fmt.Fprintf(&w, "\tfor _, v := range p.%s {\n\t\t"+set+"\t}\n", p.field, stringExpr("v", p.typ[2:]))
+			case strings.HasPrefix(p.typ, "[]"):
+				fmt.Fprintf(&w, "\tif len(p.%s) > 0 {\n\t\t"+set+"\t}\n", p.field, "strings.Trim(fmt.Sprint(p."+p.field+"), \"[]\")")
+			case p.pointer():
+				fmt.Fprintf(&w, "\tif p.%s != nil {\n\t\t"+set+"\t}\n", p.field, stringExpr("*p."+p.field, p.typ))
+			default:
+				fmt.Fprintf(&w, "\t"+set, stringExpr("p."+p.field, p.typ))
+			}
+		}
+		if in == "query" {
+			w.WriteString("\treturn q\n}\n\n")
+		} else {
+			w.WriteString("\treturn h\n}\n\n")
+		}
+	}
+
+	g.types[name] = w.String()
+}
+
+func (p *param) pointer() bool {
+	return !p.required && !strings.HasPrefix(p.typ, "[]") && !strings.HasPrefix(p.typ, "map[")
+}
+
+func (p *param) fieldType() string {
+	if p.pointer() {
+		return "*" + p.typ
+	}
+	return p.typ
+}
+
+func stringExpr(v, typ string) string {
+	switch typ {
+	case "string":
+		return v
+	case "time.Time":
+		return "(" + v + ").Format(time.RFC3339)"
+	}
+	return "fmt.Sprint(" + v + ")"
+}
+
+func (g *generator) defineNamed(name string, s *Schema) error {
+	hint := name + "Item"
+	if s.Ref == "" && (isObject(s) || isStringEnum(s)) {
+		hint = name
+	}
+
+	typ, err := g.goType(s, hint)
+	if err != nil || typ == name {
+		return err
+	}
+	var w bytes.Buffer
+	writeDoc(&w, name, s.Description)
+	fmt.Fprintf(&w, "type %s %s\n", name, typ)
+	g.types[name] = w.String()
+	return nil
+}
+
+func isObject(s *Schema) bool {
+	return s.Type == "object" || len(s.Properties) > 0 || len(s.AllOf) > 0 || (s.Type == "" && len(s.AdditionalProperties) > 0)
+}
+
+func isStringEnum(s *Schema) bool {
+	return s.Type == "string" && len(s.Enum) > 0
+}
+
+// 返回 Schema 对应的 Go 类型，对象和枚举会以 hint 为名定义新类型
+func (g *generator) goType(s *Schema, hint string) (string, error) {
+	if s == nil {
+		return "interface{}", nil
+	}
+
+	if s.Ref != "" {
+		name := refName(s.Ref)
+		if _, ok := g.spec.Components.Schemas[name]; !ok || !strings.HasPrefix(s.Ref, "#/components/schemas/") {
+			return "", fmt.Errorf("unresolved schema %s", s.Ref)
+		}
+		return goName(name), nil
+	}
+
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.goType(s.AllOf[0], hint)
+	}
+
+	if isStringEnum(s) {
+		return hint, g.defineEnum(hint, s)
+	}
+
+	switch s.Type {
+	case "array":
+		item, err := g.goType(s.Items, hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "string":
+		if s.Format == "date-time" {
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int32" || s.Format == "int64" {
+			return s.Format, nil
+		}
+		return "int", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	}
+
+	if len(s.Properties) > 0 || len(s.AllOf) > 0 {
+		return hint, g.defineStruct(hint, s)
+	}
+	if value, ok := s.additional(); ok {
+		typ, err := g.goType(value, hint+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + typ, nil
+	}
+	if s.Type == "object" {
+		return "map[string]interface{}", nil
+	}
+	return "interface{}", nil
+}
+
+func (g *generator) defineEnum(name string, s *Schema) error {
+	if _, ok := g.types[name]; ok {
+		return nil
+	}
+
+	var w bytes.Buffer
+	writeDoc(&w, name, s.Description)
+	fmt.Fprintf(&w, "type %s string\n\nconst (\n", name)
+	for _, v := range s.Enum {
+		value := fmt.Sprint(v)
+		fmt.Fprintf(&w, "\t%s%s %s = %q\n", name, goName(value), name, value)
+	}
+	w.WriteString(")\n")
+	g.types[name] = w.String()
+	return nil
+}
+
+type field struct {
+	name     string
+	schema   *Schema
+	required bool
+}
+
+// allOf 的成员按顺序合并属性，后出现的同名属性覆盖前面的
+func (g *generator) fields(s *Schema, fields map[string]*field, depth int) error {
+	if depth > 32 {
+		return fmt.Errorf("allOf nesting is too deep")
+	}
+	for _, member := range s.AllOf {
+		if member.Ref != "" {
+			ref, ok := g.spec.Components.Schemas[refName(member.Ref)]
+			if !ok {
+				return fmt.Errorf("unresolved schema %s", member.Ref)
+			}
+			member = ref
+		}
+		if err := g.fields(member, fields, depth+1); err != nil {
+			return err
+		}
+	}
+	for name, prop := range s.Properties {
+		fields[name] = &field{name: name, schema: prop}
+	}
+	for _, name := range s.Required {
+		if f, ok := fields[name]; ok {
+			f.required = true
+		}
+	}
+	return nil
+}
+
+func (g *generator) defineStruct(name string, s *Schema) error {
+	if g.structs[name] {
+		return nil
+	}
+	g.structs[name] = true
+
+	fields := map[string]*field{}
+	if err := g.fields(s, fields, 0); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var w bytes.Buffer
+	writeDoc(&w, name, s.Description)
+	fmt.Fprintf(&w, "type %s struct {\n", name)
+	for _, k := range names {
+		f := fields[k]
+		typ, err := g.goType(f.schema, name+goName(k))
+		if err != nil {
+			return fmt.Errorf("property %s: %v", k, err)
+		}
+		if (f.schema.Nullable || !f.required) && g.isStructType(typ) {
+			typ = "*" + typ
+		} else if f.schema.Nullable && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") && typ != "interface{}" {
+			typ = "*" + typ
+		}
+		tag := k
+		if !f.required {
+			tag += ",omitempty"
+		}
+		writeComment(&w, "\t", f.schema.Description)
+		fmt.Fprintf(&w, "\t%s %s `json:%q`\n", goName(k), typ, tag)
+	}
+	w.WriteString("}\n")
+	g.types[name] = w.String()
+	return nil
+}
+
+// omitempty 对结构体无效，可选的结构体字段使用指针
+func (g *generator) isStructType(typ string) bool {
+	if typ == "time.Time" || g.structs[typ] {
+		return true
+	}
+	//引用的组件可能还没有定义
+	for name, s := range g.spec.Components.Schemas {
+		if goName(name) == typ {
+			return s.Ref == "" && len(s.Properties)+len(s.AllOf) > 0
+		}
+	}
+	return false
+}
+
+func operationName(method, path string, op *Operation) string {
+	if op.OperationId != "" {
+		return goName(op.OperationId)
+	}
+	return goName(strings.ToLower(method) + " " + strings.NewReplacer("{", "by ", "}", "").Replace(path))
+}
+
+// 转换为导出的驼峰名称，如 pet_id、pet-id 转换为 PetId
+func goName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteString("X")
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return "X"
+	}
+	return b.String()
+}
+
+func lowerName(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func writeDoc(w *bytes.Buffer, name, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	lines := strings.Split(doc, "\n")
+	first := lines[0]
+	if len(first) > 1 && unicode.IsUpper(rune(first[0])) && unicode.IsLower(rune(first[1])) {
+		first = strings.ToLower(first[:1]) + first[1:]
+	}
+	fmt.Fprintf(w, "// %s %s\n", name, first)
+	for _, line := range lines[1:] {
+		fmt.Fprintf(w, "// %s\n", strings.TrimRight(line, " \t"))
+	}
+}
+
+func writeComment(w *bytes.Buffer, indent string, doc string) {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(w, "%s// %s\n", indent, strings.TrimRight(line, " \t"))
+	}
+}
+
+func sortedSchemaKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xuyang404/goutils/HttpClient"
+	"github.com/xuyang404/goutils/cmd/openapi-gen/internal/petstore"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// internal/petstore 即 golden 文件，同时参与编译和下面的端到端测试
+func TestGenerate_Golden(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := ParseSpec(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(spec, "petstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := "internal/petstore/client.go"
+	if *update {
+		if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Fatalf("generated code differs from %s, run go test -update to refresh it:\n%s", golden, src)
+	}
+}
+
+func TestGenerate_JSONSpec(t *testing.T) {
+	spec, err := ParseSpec([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Users", "version": "2"},
+		"paths": {
+			"/users/{id}": {
+				"get": {
+					"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}},
+						{"name": "type", "in": "query", "schema": {"type": "boolean"}}],
+					"responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "object", "properties": {"id": {"type": "string"}}}}}}}
+				}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := Generate(spec, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"func (c *Client) GetUsersById(id string, params *GetUsersByIdParams) (GetUsersByIdResponse, error) {",
+		`reqUrl := c.BaseUrl + "/users/" + url.PathEscape(id)`,
+		"Type *bool",
+		"type GetUsersByIdResponse struct {",
+	} {
+		if !strings.Contains(string(src), s) {
+			t.Fatalf("generated code does not contain %q:\n%s", s, src)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := ParseSpec([]byte(`{"swagger": "2.0"}`)); err == nil {
+		t.Fatal("expected error for swagger 2.0")
+	}
+
+	spec, err := ParseSpec([]byte(`
+openapi: 3.0.0
+paths:
+  /a:
+    get:
+      responses:
+        '200':
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Missing'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(spec, "a"); err == nil || !strings.Contains(err.Error(), "unresolved schema") {
+		t.Fatalf("expected unresolved schema error, got %v", err)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, expected := range map[string]string{
+		"pet_id":       "PetId",
+		"X-Request-Id": "XRequestId",
+		"listPets":     "ListPets",
+		"sold-out":     "SoldOut",
+		"2fa":          "X2fa",
+		"":             "X",
+	} {
+		if s := goName(in); s != expected {
+			t.Errorf("goName(%q) = %q, expected %q", in, s, expected)
+		}
+	}
+}
+
+func TestPetstoreClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/pets":
+			if r.URL.RawQuery != "limit=2&status=available&tags=a&tags=b" || r.Header.Get("X-Request-Id") != "rid" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`[{"id":1,"name":"cat","status":"available"},{"id":2,"name":"dog","tag":"d"}]`))
+		case "POST /v1/pets":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":3,` + strings.TrimPrefix(string(body), "{")))
+		case "PATCH /v1/pets/3":
+			w.Write([]byte(`{"pet":{"id":3,"name":"x"},"updatedAt":"2020-01-02T03:04:05Z"}`))
+		case "DELETE /v1/pets/3":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /v1/stores/a b/inventory":
+			w.Write([]byte(`{"available":7}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := petstore.NewClient(server.URL + "/v1/")
+
+	pets, err := c.ListPets(petstore.NewListPetsParams().
+		SetLimit(2).
+		SetTags([]string{"a", "b"}).
+		SetStatus(petstore.PetStatusAvailable).
+		SetXRequestId("rid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pets) != 2 || pets[0].Status != petstore.PetStatusAvailable || *pets[1].Tag != "d" {
+		t.Fatalf("unexpected pets %+v", pets)
+	}
+
+	pet, err := c.CreatePet(petstore.NewPet{Name: "fish", Owner: &petstore.Owner{Name: "me"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pet.Id != 3 || pet.Name != "fish" || pet.Owner.Name != "me" {
+		t.Fatalf("unexpected pet %+v", pet)
+	}
+
+	updated, err := c.UpdatePet(3, petstore.UpdatePetRequest{Name: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Pet.Name != "x" || updated.UpdatedAt.Year() != 2020 {
+		t.Fatalf("unexpected response %+v", updated)
+	}
+
+	if err := c.DeletePetsByPetId(3); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := c.GetInventory("a b")
+	if err != nil || inventory["available"] != 7 {
+		t.Fatalf("unexpected inventory %v %v", inventory, err)
+	}
+
+	_, err = c.ShowPetById(404)
+	if statusErr, ok := err.(*HttpClient.StatusError); !ok || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected StatusError, got %v", err)
+	}
+}
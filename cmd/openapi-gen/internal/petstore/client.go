@@ -0,0 +1,198 @@
+// Code generated by openapi-gen. DO NOT EDIT.
+
+package petstore
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xuyang404/goutils/HttpClient"
+)
+
+// DefaultBaseUrl is the first server declared in the spec.
+const DefaultBaseUrl = "https://petstore.example.com/v1"
+
+// Client calls the Petstore 1.0.0 API.
+type Client struct {
+	BaseUrl string
+	// NewRequest creates the HttpClient.Request used by each call; replace it to
+	// configure timeouts, auth, middlewares and so on.
+	NewRequest func() *HttpClient.Request
+}
+
+func NewClient(baseUrl string) *Client {
+	return &Client{
+		BaseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		NewRequest: HttpClient.NewRequest,
+	}
+}
+
+// ListPets list all pets
+//
+// GET /pets
+func (c *Client) ListPets(params *ListPetsParams) ([]Pet, error) {
+	reqUrl := c.BaseUrl + "/pets"
+	req := c.NewRequest()
+	if params != nil {
+		if q := params.query().Encode(); q != "" {
+			reqUrl += "?" + q
+		}
+		req.AddHeaders(params.headers())
+	}
+	return HttpClient.DoJSON[[]Pet](req, "GET", reqUrl, nil)
+}
+
+// CreatePet create a pet
+//
+// POST /pets
+func (c *Client) CreatePet(body NewPet) (Pet, error) {
+	reqUrl := c.BaseUrl + "/pets"
+	req := c.NewRequest()
+	return HttpClient.SendJSON[NewPet, Pet](req, "POST", reqUrl, body)
+}
+
+// ShowPetById info for a specific pet
+//
+// GET /pets/{petId}
+func (c *Client) ShowPetById(petId int64) (Pet, error) {
+	reqUrl := c.BaseUrl + "/pets/" + url.PathEscape(fmt.Sprint(petId))
+	req := c.NewRequest()
+	return HttpClient.DoJSON[Pet](req, "GET", reqUrl, nil)
+}
+
+// UpdatePet partially update a pet.
+// Only the given fields are changed.
+//
+// PATCH /pets/{petId}
+func (c *Client) UpdatePet(petId int64, body UpdatePetRequest) (UpdatePetResponse, error) {
+	reqUrl := c.BaseUrl + "/pets/" + url.PathEscape(fmt.Sprint(petId))
+	req := c.NewRequest()
+	return HttpClient.SendJSON[UpdatePetRequest, UpdatePetResponse](req, "PATCH", reqUrl, body)
+}
+
+// DELETE /pets/{petId}
+//
+// Deprecated: the operation is deprecated in the spec.
+func (c *Client) DeletePetsByPetId(petId int64) error {
+	reqUrl := c.BaseUrl + "/pets/" + url.PathEscape(fmt.Sprint(petId))
+	req := c.NewRequest()
+	_, err := HttpClient.DoJSON[HttpClient.NoContent](req, "DELETE", reqUrl, nil)
+	return err
+}
+
+// GET /stores/{storeId}/inventory
+func (c *Client) GetInventory(storeId string) (map[string]int64, error) {
+	reqUrl := c.BaseUrl + "/stores/" + url.PathEscape(storeId) + "/inventory"
+	req := c.NewRequest()
+	return HttpClient.DoJSON[map[string]int64](req, "GET", reqUrl, nil)
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ListPetsParams holds the query and header parameters of ListPets.
+type ListPetsParams struct {
+	// How many items to return at one time
+	Limit      *int32
+	Tags       []string
+	Status     PetStatus
+	XRequestId *string
+}
+
+func NewListPetsParams() *ListPetsParams {
+	return &ListPetsParams{}
+}
+
+func (p *ListPetsParams) SetLimit(v int32) *ListPetsParams {
+	p.Limit = &v
+	return p
+}
+
+func (p *ListPetsParams) SetTags(v []string) *ListPetsParams {
+	p.Tags = v
+	return p
+}
+
+func (p *ListPetsParams) SetStatus(v PetStatus) *ListPetsParams {
+	p.Status = v
+	return p
+}
+
+func (p *ListPetsParams) SetXRequestId(v string) *ListPetsParams {
+	p.XRequestId = &v
+	return p
+}
+
+func (p *ListPetsParams) query() url.Values {
+	q := url.Values{}
+	if p.Limit != nil {
+		q.Add("limit", fmt.Sprint(*p.Limit))
+	}
+	for _, v := range p.Tags {
+		q.Add("tags", v)
+	}
+	q.Add("status", fmt.Sprint(p.Status))
+	return q
+}
+
+func (p *ListPetsParams) headers() map[string]string {
+	h := map[string]string{}
+	if p.XRequestId != nil {
+		h["X-Request-Id"] = *p.XRequestId
+	}
+	return h
+}
+
+type NewPet struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Name       string            `json:"name"`
+	Owner      *Owner            `json:"owner,omitempty"`
+	Status     PetStatus         `json:"status,omitempty"`
+	Tag        *string           `json:"tag,omitempty"`
+}
+
+type Owner struct {
+	Name   string            `json:"name,omitempty"`
+	Phones []OwnerPhonesItem `json:"phones,omitempty"`
+}
+
+type OwnerPhonesItem struct {
+	Kind   string `json:"kind,omitempty"`
+	Number string `json:"number,omitempty"`
+}
+
+// Pet A pet with its id.
+type Pet struct {
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Id         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Owner      *Owner            `json:"owner,omitempty"`
+	PhotoUrls  []string          `json:"photoUrls,omitempty"`
+	Status     PetStatus         `json:"status,omitempty"`
+	Tag        *string           `json:"tag,omitempty"`
+}
+
+// PetStatus status of the pet in the store
+type PetStatus string
+
+const (
+	PetStatusAvailable PetStatus = "available"
+	PetStatusPending   PetStatus = "pending"
+	PetStatusSoldOut   PetStatus = "sold-out"
+)
+
+type Pets []Pet
+
+type UpdatePetRequest struct {
+	Name   string    `json:"name,omitempty"`
+	Status PetStatus `json:"status,omitempty"`
+}
+
+type UpdatePetResponse struct {
+	Pet       Pet        `json:"pet"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
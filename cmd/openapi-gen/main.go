@@ -0,0 +1,77 @@
+// openapi-gen 根据 OpenAPI 3 文档（JSON 或 YAML）生成基于 HttpClient 的类型化客户端
+//
+//	go run github.com/xuyang404/goutils/cmd/openapi-gen -spec petstore.yaml -package petstore -o petstore/client.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specFile := flag.String("spec", "", "OpenAPI 3 文档路径，支持 JSON 和 YAML")
+	pkg := flag.String("package", "", "生成代码的包名，默认使用输出目录名")
+	output := flag.String("o", "", "输出文件，默认输出到标准输出")
+	flag.Parse()
+
+	if *specFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*specFile, *pkg, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "openapi-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specFile, pkg, output string) error {
+	b, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return err
+	}
+
+	spec, err := ParseSpec(b)
+	if err != nil {
+		return err
+	}
+
+	if pkg == "" {
+		pkg = "client"
+		if output != "" {
+			if abs, err := filepath.Abs(output); err == nil {
+				pkg = goPackageName(filepath.Base(filepath.Dir(abs)))
+			}
+		}
+	}
+
+	src, err := Generate(spec, pkg)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(output, src, 0644)
+}
+
+func goPackageName(dir string) string {
+	name := make([]rune, 0, len(dir))
+	for _, r := range dir {
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9' && len(name) > 0) || r == '_' {
+			name = append(name, r)
+		}
+	}
+	if len(name) == 0 {
+		return "client"
+	}
+	return string(name)
+}
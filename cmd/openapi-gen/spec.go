@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// OpenAPI 3 文档中生成客户端需要用到的部分
+
+type Spec struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	Url string `json:"url"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies"`
+	Responses     map[string]*Response    `json:"responses"`
+}
+
+type PathItem struct {
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Patch      *Operation   `json:"patch"`
+	Parameters []*Parameter `json:"parameters"`
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Deprecated  bool                 `json:"deprecated"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string                `json:"$ref"`
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []interface{}      `json:"enum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *Schema            `json:"items"`
+	AllOf                []*Schema          `json:"allOf"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+}
+
+// additionalProperties 可以是布尔值或 Schema
+func (s *Schema) additional() (*Schema, bool) {
+	raw := strings.TrimSpace(string(s.AdditionalProperties))
+	if raw == "" || raw == "false" {
+		return nil, false
+	}
+	if raw == "true" {
+		return &Schema{}, true
+	}
+	schema := &Schema{}
+	if err := json.Unmarshal(s.AdditionalProperties, schema); err != nil {
+		return &Schema{}, true
+	}
+	return schema, true
+}
+
+func (s *Schema) isRequired(name string) bool {
+	for _, v := range s.Required {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析 JSON 或 YAML 格式的 OpenAPI 文档
+func ParseSpec(b []byte) (*Spec, error) {
+	trimmed := strings.TrimSpace(string(b))
+	if !strings.HasPrefix(trimmed, "{") {
+		var doc interface{}
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, err
+		}
+		v, err := yamlToJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	spec := &Spec{}
+	if err := json.Unmarshal(b, spec); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q, only 3.x is supported", spec.OpenAPI)
+	}
+	return spec, nil
+}
+
+// yaml.v2 解析出的 map 的键是 interface{}，转换后才能编码为 JSON
+func yamlToJSON(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			item, err := yamlToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(k)] = item
+		}
+		return m, nil
+	case []interface{}:
+		for i, item := range v {
+			item, err := yamlToJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = item
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func (s *Spec) parameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	if v, ok := s.Components.Parameters[refName(p.Ref)]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("unresolved parameter %s", p.Ref)
+}
+
+func (s *Spec) requestBody(b *RequestBody) (*RequestBody, error) {
+	if b == nil || b.Ref == "" {
+		return b, nil
+	}
+	if v, ok := s.Components.RequestBodies[refName(b.Ref)]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("unresolved request body %s", b.Ref)
+}
+
+func (s *Spec) response(r *Response) (*Response, error) {
+	if r == nil || r.Ref == "" {
+		return r, nil
+	}
+	if v, ok := s.Components.Responses[refName(r.Ref)]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("unresolved response %s", r.Ref)
+}
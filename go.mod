@@ -1,10 +1,25 @@
 module github.com/xuyang404/goutils
 
-go 1.13
+go 1.18
 
 require (
+	github.com/andybalholm/brotli v1.0.1
 	github.com/faabiosr/cachego v0.16.1
 	github.com/go-redis/redis/v8 v8.0.0-beta.10
-	github.com/json-iterator/go v1.1.10
+	github.com/gorilla/websocket v1.4.2
+	github.com/json-iterator/go v1.1.12
+	github.com/klauspost/compress v1.11.4
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/techoner/gophp v0.2.0
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
+	gopkg.in/yaml.v2 v2.3.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/modern-go/concurrent v0.0.0-20180228061459-e0a39a4cb421 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	go.opentelemetry.io/otel v0.11.0 // indirect
+	golang.org/x/exp v0.0.0-20200821190819-94841d0725da // indirect
 )